@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+)
+
+// achievements file, within the data directory
+const achievementsFile = "achievements.json"
+
+// ticks an achievement toast is shown for
+const toastTicks = 180
+
+// game events achievements are driven by
+type achievementEvent uint8
+
+const (
+	// a tick of play passed
+	EventTick achievementEvent = iota + 1
+
+	// the snake ate a cupcake
+	EventCupcake
+
+	// the snake crossed the edge of the board (wrapping around)
+	EventWrap
+
+	// the snake died
+	EventGameOver
+)
+
+// counts of things achievements measure
+type GameStats struct {
+	Cupcakes int `json:"cupcakes"`
+	Wraps    int `json:"wraps"`
+	Ticks    int `json:"ticks"`
+	Games    int `json:"games"`
+}
+
+// an achievement: unlocked once its value reaches its goal
+type Achievement struct {
+
+	// id, used in the achievements file
+	id string
+
+	// message keys of the achievement's name & description
+	name string
+	desc string
+
+	// value needed to unlock
+	goal int
+
+	// current value
+	value func(g *Game) int
+
+	// format a value for the achievements screen (nil for a plain number)
+	format func(v int) string
+}
+
+// all achievements, in the order they are listed
+var achievements = []Achievement{
+	{
+		id:    "first_cupcake",
+		name:  "achievement.first_cupcake",
+		desc:  "achievement.first_cupcake.desc",
+		goal:  1,
+		value: func(g *Game) int { return g.stats.Cupcakes },
+	},
+	{
+		id:    "cupcakes_50",
+		name:  "achievement.cupcakes_50",
+		desc:  "achievement.cupcakes_50.desc",
+		goal:  50,
+		value: func(g *Game) int { return g.stats.Cupcakes },
+	},
+	{
+		id:     "survive_5",
+		name:   "achievement.survive_5",
+		desc:   "achievement.survive_5.desc",
+		goal:   5 * 60 * 60,
+		value:  func(g *Game) int { return g.stats.Ticks },
+		format: ReplayTime,
+	},
+	{
+		id:    "fill_25",
+		name:  "achievement.fill_25",
+		desc:  "achievement.fill_25.desc",
+		goal:  25,
+		value: func(g *Game) int { return g.SnakeBody.length * 100 / (g.width * g.height) },
+		format: func(v int) string {
+			return fmt.Sprintf("%d%%", v)
+		},
+	},
+	{
+		id:    "wrap_100",
+		name:  "achievement.wrap_100",
+		desc:  "achievement.wrap_100.desc",
+		goal:  100,
+		value: func(g *Game) int { return g.achievements.Totals.Wraps },
+	},
+	{
+		id:    "games_10",
+		name:  "achievement.games_10",
+		desc:  "achievement.games_10.desc",
+		goal:  10,
+		value: func(g *Game) int { return g.achievements.Totals.Games },
+	},
+	{
+		id:    "cupcakes_1000",
+		name:  "achievement.cupcakes_1000",
+		desc:  "achievement.cupcakes_1000.desc",
+		goal:  1000,
+		value: func(g *Game) int { return g.achievements.Totals.Cupcakes },
+	},
+	{
+		id:   "perfect",
+		name: "achievement.perfect",
+		desc: "achievement.perfect.desc",
+		goal: 1,
+		value: func(g *Game) int {
+			if g.state == StateGameWon {
+				return 1
+			}
+			return 0
+		},
+	},
+	{
+		id:    "daily_7",
+		name:  "achievement.daily_7",
+		desc:  "achievement.daily_7.desc",
+		goal:  7,
+		value: func(g *Game) int { return g.dailyResults.Streak(DailyChallenge(time.Now()).Date) },
+	},
+}
+
+// achievement progress, saved between games
+type AchievementRecord struct {
+
+	// when each unlocked achievement was unlocked, by id
+	Unlocked map[string]time.Time `json:"unlocked"`
+
+	// best value reached towards each achievement, by id
+	Progress map[string]int `json:"progress"`
+
+	// totals over all games
+	Totals GameStats `json:"totals"`
+}
+
+// load achievement progress from the data directory
+// if there is no achievements file, empty progress is returned
+func LoadAchievements() (*AchievementRecord, error) {
+	a := AchievementRecord{
+		Unlocked: make(map[string]time.Time),
+		Progress: make(map[string]int),
+	}
+	path, err := DataPath(achievementsFile)
+	if err != nil {
+		return &a, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &a, nil
+	}
+	if err != nil {
+		return &a, err
+	}
+	err = json.Unmarshal(b, &a)
+	if err != nil {
+		return &a, fmt.Errorf("%s: %w", path, err)
+	}
+	return &a, nil
+}
+
+// save achievement progress to the data directory
+func (a *AchievementRecord) Save() error {
+	path, err := DataPath(achievementsFile)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// handle a game event: update stats, and unlock any achievements reached
+// games without achievements (replays being played back, the ghost) ignore events
+func (g *Game) AchievementEvent(e achievementEvent) {
+	a := g.achievements
+	if a == nil {
+		return
+	}
+
+	switch e {
+	case EventTick:
+		g.stats.Ticks++
+	case EventCupcake:
+		g.stats.Cupcakes++
+		a.Totals.Cupcakes++
+	case EventWrap:
+		g.stats.Wraps++
+		a.Totals.Wraps++
+	case EventGameOver:
+		a.Totals.Games++
+		a.Totals.Ticks += g.stats.Ticks
+	}
+
+	// check achievements
+	changed := e == EventGameOver
+	for _, ach := range achievements {
+		if _, ok := a.Unlocked[ach.id]; ok {
+			continue
+		}
+		v := ach.value(g)
+		if v > a.Progress[ach.id] {
+			a.Progress[ach.id] = min(v, ach.goal)
+		}
+		if v >= ach.goal {
+			a.Unlocked[ach.id] = time.Now()
+			g.toasts = append(g.toasts, ach.name)
+			changed = true
+		}
+	}
+
+	if changed {
+		err := a.Save()
+		if err != nil {
+			log.Printf("could not save achievements: %v", err)
+		}
+	}
+}
+
+// update the achievement toast
+func (g *Game) UpdateToasts() {
+	if len(g.toasts) == 0 {
+		return
+	}
+	g.toastTicks++
+	if g.toastTicks >= toastTicks {
+		g.toastTicks = 0
+		g.toasts = g.toasts[1:]
+	}
+}
@@ -0,0 +1,69 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// draw the achievement toast (if any), sliding down from under the score bar
+func (g *Game) DrawToast(imgOut *ebiten.Image) {
+	if len(g.toasts) == 0 {
+		return
+	}
+	w, _ := g.ScreenSize()
+	slide := min(g.toastTicks, toastTicks-g.toastTicks, 10)
+	y := float32(TILESIZE - 32 + slide*4)
+	vector.DrawFilledRect(imgOut, float32(w)/2-130, y, 260, 32, g.PaletteColour(colourDark), false)
+	vector.StrokeRect(imgOut, float32(w)/2-130, y, 260, 32, 1, g.PaletteColour(colourSnakeYellow), false)
+	g.DrawTextCentred(imgOut, g.T("achievements.unlocked"), float64(y)+5, TextStyle{Colour: textHighlightColour})
+	g.DrawTextCentred(imgOut, g.T(g.toasts[0]), float64(y)+18, TextStyle{})
+}
+
+// update function for when in the achievements screen
+func (g *Game) UpdateAchievements() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.ChangeState(StateMainMenu)
+	}
+	return nil
+}
+
+// draw the achievements screen: every achievement, with a progress bar for those still locked
+func (g *Game) DrawAchievements(imgOut *ebiten.Image) {
+	w, _ := g.ScreenSize()
+	left := float64(w)/2 - 150
+	right := float64(w)/2 + 150
+	g.DrawTextCentred(imgOut, g.T("achievements.title"), 24, TextStyle{Colour: textHighlightColour, Outline: textOutlineColour})
+	for i, ach := range achievements {
+		y := float64(44 + i*28)
+		style := TextStyle{Outline: textOutlineColour}
+		_, unlocked := g.achievements.Unlocked[ach.id]
+		progress := g.achievements.Progress[ach.id]
+		status := fmt.Sprintf("%d/%d", progress, ach.goal)
+		if ach.format != nil {
+			status = ach.format(progress) + "/" + ach.format(ach.goal)
+		}
+		if unlocked {
+			style.Colour = textHighlightColour
+			progress = ach.goal
+			status = g.T("achievements.done")
+		}
+		g.DrawText(imgOut, g.T(ach.name), left, y, style)
+		g.DrawText(imgOut, g.T(ach.desc), left, y+11, TextStyle{Outline: textOutlineColour, Colour: textDimColour})
+		style.Align = text.AlignEnd
+		g.DrawText(imgOut, status, right, y, style)
+
+		// progress bar
+		bw := float32(right - left)
+		vector.DrawFilledRect(imgOut, float32(left), float32(y)+22, bw, 3, g.PaletteColour(colourDark), false)
+		vector.DrawFilledRect(imgOut, float32(left), float32(y)+22, bw*float32(progress)/float32(ach.goal), 3, g.PaletteColour(colourSnakeYellow), false)
+	}
+	g.DrawKeyHelp(imgOut, 310, [][2]string{
+		{g.T("key.esc"), g.T("settings.back")},
+	}, TextStyle{Outline: textOutlineColour})
+}
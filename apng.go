@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+)
+
+// PNG file signature
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// animated PNG (APNG): each frame is encoded as a PNG, and its image data repackaged as a frame of the animation
+// the first frame is also the PNG's image, so viewers without APNG support show that
+// every frame is kept (compressed) until the animation is written, as the frame count comes first
+type APNG struct {
+
+	// header (IHDR chunk data) of the first frame, which every frame must match
+	header []byte
+
+	// each frame's compressed image data (its IDAT chunks' data, joined)
+	frames [][]byte
+
+	// time each frame is shown for, in seconds: delayNum/delayDen
+	delayNum, delayDen uint16
+}
+
+// return a new, empty animation, showing each frame for delayNum/delayDen seconds
+func NewAPNG(delayNum, delayDen int) *APNG {
+	return &APNG{delayNum: uint16(delayNum), delayDen: uint16(delayDen)}
+}
+
+// add img as the next frame (the same size & colour type as the first frame)
+func (a *APNG) Add(img image.Image) error {
+	var b bytes.Buffer
+	err := png.Encode(&b, img)
+	if err != nil {
+		return err
+	}
+
+	// pick out the header & image data
+	var header, data []byte
+	r := bytes.NewReader(b.Bytes()[len(pngSignature):])
+	for {
+		typ, chunk, err := readPNGChunk(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case "IHDR":
+			header = chunk
+		case "IDAT":
+			data = append(data, chunk...)
+		}
+	}
+	if a.header == nil {
+		a.header = header
+	} else if !bytes.Equal(header, a.header) {
+		return fmt.Errorf("APNG frame %d differs in size or colour type from the first frame", len(a.frames))
+	}
+	a.frames = append(a.frames, data)
+	return nil
+}
+
+// write the animation to w, looping forever
+func (a *APNG) Encode(w io.Writer) error {
+	if len(a.frames) == 0 {
+		return errors.New("APNG has no frames")
+	}
+	width := binary.BigEndian.Uint32(a.header[0:4])
+	height := binary.BigEndian.Uint32(a.header[4:8])
+
+	_, err := w.Write(pngSignature)
+	if err != nil {
+		return err
+	}
+	err = writePNGChunk(w, "IHDR", a.header)
+	if err != nil {
+		return err
+	}
+
+	// animation control: number of frames, and plays (0 loops forever)
+	err = writePNGChunk(w, "acTL", binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, uint32(len(a.frames))), 0))
+	if err != nil {
+		return err
+	}
+
+	// each frame: a frame control chunk, then its image data (as IDAT for the first frame, fdAT after),
+	// with every fcTL & fdAT chunk numbered in sequence
+	seq := uint32(0)
+	for i, data := range a.frames {
+		fctl := binary.BigEndian.AppendUint32(nil, seq)
+		fctl = binary.BigEndian.AppendUint32(fctl, width)
+		fctl = binary.BigEndian.AppendUint32(fctl, height)
+		fctl = binary.BigEndian.AppendUint32(fctl, 0) // x offset
+		fctl = binary.BigEndian.AppendUint32(fctl, 0) // y offset
+		fctl = binary.BigEndian.AppendUint16(fctl, a.delayNum)
+		fctl = binary.BigEndian.AppendUint16(fctl, a.delayDen)
+		fctl = append(fctl, 0, 0) // dispose: none, blend: source (every frame covers the whole image)
+		err = writePNGChunk(w, "fcTL", fctl)
+		if err != nil {
+			return err
+		}
+		seq++
+
+		if i == 0 {
+			err = writePNGChunk(w, "IDAT", data)
+		} else {
+			err = writePNGChunk(w, "fdAT", append(binary.BigEndian.AppendUint32(nil, seq), data...))
+			seq++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return writePNGChunk(w, "IEND", nil)
+}
+
+// read a PNG chunk from r, returning its type & data (io.EOF once there are none left)
+func readPNGChunk(r io.Reader) (string, []byte, error) {
+	var head [8]byte
+	_, err := io.ReadFull(r, head[:])
+	if err != nil {
+		return "", nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(head[:4])+4)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(head[4:]), data[:len(data)-4], nil
+}
+
+// write a PNG chunk of type typ to w
+func writePNGChunk(w io.Writer, typ string, data []byte) error {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(b, typ...)
+	b = append(b, data...)
+	b = binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// a named rectangle within the atlas image, in pixels
+type atlasRegion struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// the atlas manifest, describing the regions & animations within the atlas image
+type atlasManifest struct {
+
+	// named regions within the atlas image
+	Regions map[string]atlasRegion `json:"regions"`
+
+	// named animations, each a list of region names (one per frame)
+	Animations map[string][]string `json:"animations"`
+}
+
+// sprite atlas: one image containing many sprites
+type Atlas struct {
+
+	// the whole atlas image, for drawing in a window (see atlasView)
+	atlasView
+
+	// the atlas image as decoded, before any colour remapping
+	src image.Image
+
+	// sprite regions by name
+	regions map[string]image.Rectangle
+
+	// animation frames (region names) by name
+	animations map[string][]string
+}
+
+// decode an atlas from PNG image data and JSON manifest data, without creating its ebiten image
+// (for drawing without a window, see SoftRenderer)
+func DecodeAtlas(pngData, manifestData []byte) (*Atlas, error) {
+	var m atlasManifest
+
+	// decode image
+	i, _, err := image.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		return nil, err
+	}
+
+	// decode manifest
+	err = json.Unmarshal(manifestData, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	a := Atlas{
+		src:        i,
+		regions:    make(map[string]image.Rectangle),
+		animations: m.Animations,
+	}
+
+	// check each region lies within the image
+	for name, r := range m.Regions {
+		rect := image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H)
+		if rect.Empty() || !rect.In(i.Bounds()) {
+			return nil, fmt.Errorf("atlas region %q %v outside of image %v", name, rect, i.Bounds())
+		}
+		a.regions[name] = rect
+	}
+
+	// check each animation frame refers to a region
+	for name, frames := range a.animations {
+		if len(frames) == 0 {
+			return nil, fmt.Errorf("atlas animation %q has no frames", name)
+		}
+		for _, f := range frames {
+			if _, ok := a.regions[f]; !ok {
+				return nil, fmt.Errorf("atlas animation %q refers to unknown region %q", name, f)
+			}
+		}
+	}
+
+	return &a, nil
+}
+
+// return a copy of the decoded atlas image, with every pixel's colour remapped with f
+func (a *Atlas) RemapSource(f func(color.RGBA) color.RGBA) *image.RGBA {
+	b := a.src.Bounds()
+	remapped := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(a.src.At(x, y)).(color.RGBA)
+			remapped.SetRGBA(x, y, f(c))
+		}
+	}
+	return remapped
+}
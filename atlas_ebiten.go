@@ -0,0 +1,60 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// the atlas's ebiten image (see Atlas)
+type atlasView struct {
+
+	// the whole atlas image
+	img *ebiten.Image
+}
+
+// load an atlas from PNG image data and JSON manifest data
+func LoadAtlas(pngData, manifestData []byte) (*Atlas, error) {
+	a, err := DecodeAtlas(pngData, manifestData)
+	if err != nil {
+		return nil, err
+	}
+	a.img = ebiten.NewImageFromImage(a.src)
+	return a, nil
+}
+
+// rebuild the atlas image from the decoded image, remapping every pixel's colour with f
+// images previously returned by the atlas are no longer valid
+func (a *Atlas) Remap(f func(color.RGBA) color.RGBA) {
+	a.img.Deallocate()
+	a.img = ebiten.NewImageFromImage(a.RemapSource(f))
+}
+
+// return the named sprite from the atlas
+func (a *Atlas) Image(name string) (*ebiten.Image, error) {
+	r, ok := a.regions[name]
+	if !ok {
+		return nil, fmt.Errorf("atlas region %q not found", name)
+	}
+	return a.img.SubImage(r).(*ebiten.Image), nil
+}
+
+// return the frames of the named animation from the atlas
+func (a *Atlas) Frames(name string) ([]*ebiten.Image, error) {
+	frames, ok := a.animations[name]
+	if !ok {
+		return nil, fmt.Errorf("atlas animation %q not found", name)
+	}
+	imgs := make([]*ebiten.Image, len(frames))
+	for i, f := range frames {
+		img, err := a.Image(f)
+		if err != nil {
+			return nil, err
+		}
+		imgs[i] = img
+	}
+	return imgs, nil
+}
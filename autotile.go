@@ -0,0 +1,96 @@
+package main
+
+// kinds of snake segment sprite
+type SegmentKind uint8
+
+const (
+	SegmentHead SegmentKind = iota
+	SegmentBody
+	SegmentBend
+	SegmentTail
+)
+
+// atlas names of each kind of segment sprite
+var segmentKindNames = [...]string{"head", "body", "bend", "tail"}
+
+// the sprite to draw a snake segment with, and how to turn it
+// the sprites face up (bends join left & down), and are mirrored left to right if Mirrored,
+// then rotated clockwise by Turns quarter turns
+type SegmentTile struct {
+	Kind     SegmentKind
+	Turns    int
+	Mirrored bool
+}
+
+// return the atlas name of the tile's sprite (or its skeleton's)
+func (t SegmentTile) AtlasName(skeleton bool) string {
+	if skeleton {
+		return "snakeskeleton" + segmentKindNames[t.Kind]
+	}
+	return "snake" + segmentKindNames[t.Kind]
+}
+
+// work out the tile for segment i of the snake (0 is the head) from the segments either side of it
+// neighbours are found from the direction the snake moved into each segment rather than from positions,
+// so segments either side of an edge of the board join up however the board wraps
+func (s *SnakeBody) Tile(i int) SegmentTile {
+	seg := s.Segment(i)
+	switch {
+	case i == 0:
+		return SegmentTile{SegmentHead, directionTurns(seg.facing), seg.mirrored}
+	case i == s.length-1:
+		return SegmentTile{SegmentTail, directionTurns(s.Segment(i - 1).facing), seg.mirrored}
+	}
+
+	// straight through, or a bend joining the segments ahead & behind
+	ahead := s.Segment(i - 1).facing
+	if ahead == seg.facing {
+		return SegmentTile{SegmentBody, directionTurns(ahead), seg.mirrored}
+	}
+	return SegmentTile{SegmentBend, bendTurns(ahead, OppositeDirection(seg.facing), seg.mirrored), seg.mirrored}
+}
+
+// return the quarter turns clockwise from up to direction d
+func directionTurns(d snakeDirection) int {
+	switch d {
+	case RIGHT:
+		return 1
+	case DOWN:
+		return 2
+	case LEFT:
+		return 3
+	}
+	return 0
+}
+
+// return the quarter turns clockwise of the bend sprite to join directions a & b
+// (the bend sprite joins left & down, or right & down when mirrored)
+func bendTurns(a, b snakeDirection, mirrored bool) int {
+	joins := func(d snakeDirection) bool { return a == d || b == d }
+	turns := 3
+	switch {
+	case joins(LEFT) && joins(DOWN):
+		turns = 0
+	case joins(LEFT) && joins(UP):
+		turns = 1
+	case joins(RIGHT) && joins(UP):
+		turns = 2
+	}
+	if mirrored {
+		turns = (turns + 1) % 4
+	}
+	return turns
+}
+
+// return the opposite of direction d
+func OppositeDirection(d snakeDirection) snakeDirection {
+	switch d {
+	case UP:
+		return DOWN
+	case DOWN:
+		return UP
+	case LEFT:
+		return RIGHT
+	}
+	return LEFT
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// a game's board & state, as plain values that can be printed as text & parsed back
+// (for test fixtures, level files, bug reports & the debug dump key)
+//
+// the text is a header of "key: value" lines, then the board with a border around it:
+//
+//	rules: {"walls":true}
+//	seed: 1
+//	state: ingame
+//	score: 1
+//	points: cupcakes=200 combo=0 length=0 turns=0 shortcuts=0
+//	combo: 1 moves=3 turned=false
+//	ticks: 12/39 skeleton=0
+//	direction: L
+//	snake: grow=false mirrored=false skeleton=0 moving=true grew=false
+//	+######+
+//	#......#
+//	#.*....#
+//	#..L<^.#
+//	#....^.#
+//	#....u.#
+//	+######+
+//
+// the border shows how the edges of the board join: - and | wrap around, ~ flip the board over, # are walls
+// on the board, . is empty, * is the food, the snake's head is U, D, L or R (the way it faces),
+// its body segments ^, v, < or > (the way the snake moved into them), and its tail u, d, l or r
+// header lines can be left out (eg: in level files), leaving those values zero, or as at the start of a game
+//
+// the snake line is whether the snake grows on its next move, whether its head is mirrored, its skeleton
+// progress, then how it last moved, for smooth movement: whether it is moving, and whether its last move grew it
+// moving & grew were added to the snake line after it was first written; boards without them (saved before
+// smooth movement was recorded) still parse, with the snake at rest (both false)
+type Board struct {
+
+	// size of the board
+	Width, Height int
+
+	// rules the game is played with
+	Rules Rules
+
+	// seed for food placement
+	Seed uint64
+
+	// state of the game (start, ingame, gameend, gameover or won)
+	State gameState
+
+	// the snake, its next direction, and the food
+	Snake     *SnakeBody
+	Direction snakeDirection
+	Food      Food
+
+	// cupcakes eaten, and points scored
+	Score   int
+	Scoring Scoring
+
+	// movement & skeleton tick counters
+	Ticks            int
+	TicksPerMovement int
+	SkeleTicks       int
+}
+
+// names of the game states a board can be in
+var boardStates = map[gameState]string{
+	StateGameStart: "start",
+	StateInGame:    "ingame",
+	StateGameEnd:   "gameend",
+	StateGameOver:  "gameover",
+	StateGameWon:   "won",
+}
+
+// characters for each direction of the snake's head, body & tail, indexed by direction-1
+const (
+	boardHeadChars = "UDLR"
+	boardBodyChars = "^v<>"
+	boardTailChars = "udlr"
+)
+
+// border characters for each way edges join: top & bottom, then left & right
+var boardBorderChars = map[edgeJoin][2]byte{
+	edgeWrap: {'-', '|'},
+	edgeFlip: {'~', '~'},
+	edgeWall: {'#', '#'},
+}
+
+// return the game's board & state
+func (g *Game) Board() Board {
+	return Board{
+		Width:            g.width,
+		Height:           g.height,
+		Rules:            g.rules,
+		Seed:             g.seed,
+		State:            g.state,
+		Snake:            g.SnakeBody.Clone(),
+		Direction:        g.SnakeDirection,
+		Food:             *g.food,
+		Score:            g.score,
+		Scoring:          g.scoring,
+		Ticks:            g.ticks,
+		TicksPerMovement: g.ticksPerMovement,
+		SkeleTicks:       g.skeleTicks,
+	}
+}
+
+// return a new game without a window (see NewHeadlessGame) in the state of board b
+func NewBoardGame(b Board) (*Game, error) {
+	g, err := NewHeadlessGame(b.Width, b.Height)
+	if err != nil {
+		return nil, err
+	}
+	g.LoadBoard(b)
+	g.replay = NewReplay(g.seed, g.width, g.height, g.rules)
+	return g, nil
+}
+
+// put the game in the state of board b (the game's board must already be b's size)
+func (g *Game) LoadBoard(b Board) {
+	g.rules = b.Rules
+	g.seed = b.Seed
+	g.state = b.State
+	g.SnakeBody = b.Snake.Clone()
+	g.occupancy = NewOccupancy(g.width, g.height)
+	g.occupancy.AddSnake(g.SnakeBody)
+	g.SnakeDirection = b.Direction
+	food := b.Food
+	g.food = &food
+	g.score = b.Score
+	g.scoring = b.Scoring
+	g.ticks = b.Ticks
+	g.ticksPerMovement = b.TicksPerMovement
+	g.skeleTicks = b.SkeleTicks
+	g.ResetCamera()
+}
+
+// return a game with the board's size & rules, for working out how the board's edges join
+func (b Board) game() *Game {
+	return &Game{width: b.Width, height: b.Height, rules: b.Rules}
+}
+
+// return the board as text
+func (b Board) String() string {
+	var sb strings.Builder
+	s := b.Snake
+
+	// header
+	rules, err := json.Marshal(b.Rules)
+	if err != nil {
+		rules = []byte("{}")
+	}
+	skeleton := 0
+	for skeleton < s.length && s.Segment(skeleton).skeleton {
+		skeleton++
+	}
+	p := b.Scoring.Breakdown
+	fmt.Fprintf(&sb, "rules: %s\n", rules)
+	fmt.Fprintf(&sb, "seed: %d\n", b.Seed)
+	fmt.Fprintf(&sb, "state: %s\n", boardStates[b.State])
+	fmt.Fprintf(&sb, "score: %d\n", b.Score)
+	fmt.Fprintf(&sb, "points: cupcakes=%d combo=%d length=%d turns=%d shortcuts=%d\n", p.Cupcakes, p.Combo, p.Length, p.Turns, p.Shortcuts)
+	fmt.Fprintf(&sb, "combo: %d moves=%d turned=%t\n", b.Scoring.combo, b.Scoring.movesSinceCupcake, b.Scoring.turned)
+	fmt.Fprintf(&sb, "ticks: %d/%d skeleton=%d\n", b.Ticks, b.TicksPerMovement, b.SkeleTicks)
+	fmt.Fprintf(&sb, "direction: %c\n", boardHeadChars[b.Direction-1])
+	fmt.Fprintf(&sb, "snake: grow=%t mirrored=%t skeleton=%d moving=%t grew=%t\n", s.grow, s.Head().mirrored, skeleton, s.moving, s.grew)
+
+	// cells: food, then the snake from its tail to its head
+	cells := make([][]byte, b.Height)
+	for y := range cells {
+		cells[y] = []byte(strings.Repeat(".", b.Width))
+	}
+	cells[b.Food.y][b.Food.x] = '*'
+	for i := s.length - 1; i >= 0; i-- {
+		seg := s.Segment(i)
+		chars := boardBodyChars
+		switch i {
+		case 0:
+			chars = boardHeadChars
+		case s.length - 1:
+			chars = boardTailChars
+		}
+		cells[seg.y][seg.x] = chars[seg.facing-1]
+	}
+
+	// board, with a border showing how the edges join
+	t := b.game().Topology()
+	top := boardBorderChars[t.y][0]
+	side := boardBorderChars[t.x][1]
+	border := "+" + strings.Repeat(string(top), b.Width) + "+\n"
+	sb.WriteString(border)
+	for _, row := range cells {
+		fmt.Fprintf(&sb, "%c%s%c\n", side, row, side)
+	}
+	sb.WriteString(border)
+	return sb.String()
+}
+
+// parse a board from text (as printed by Board.String)
+func ParseBoard(text string) (Board, error) {
+	b := Board{State: StateInGame}
+	var snakeGrow, snakeMirrored, snakeMoving, snakeGrew bool
+	skeleton := 0
+	haveTicks, haveDirection := false, false
+
+	// header, up to the top border
+	var rows []string
+	sc := bufio.NewScanner(strings.NewReader(text))
+	line := 0
+	for sc.Scan() {
+		line++
+		s := strings.TrimRight(sc.Text(), " \t\r")
+		if strings.HasPrefix(s, "+") {
+			rows = append(rows, s)
+			break
+		}
+		if s == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(s, ":")
+		if !ok {
+			return b, fmt.Errorf("line %d: expected \"key: value\" or the board's border, got %q", line, s)
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "rules":
+			err = json.Unmarshal([]byte(value), &b.Rules)
+		case "seed":
+			_, err = fmt.Sscanf(value, "%d", &b.Seed)
+		case "state":
+			err = fmt.Errorf("unknown state %q", value)
+			for state, name := range boardStates {
+				if name == value {
+					b.State = state
+					err = nil
+				}
+			}
+		case "score":
+			_, err = fmt.Sscanf(value, "%d", &b.Score)
+		case "points":
+			p := &b.Scoring.Breakdown
+			_, err = fmt.Sscanf(value, "cupcakes=%d combo=%d length=%d turns=%d shortcuts=%d", &p.Cupcakes, &p.Combo, &p.Length, &p.Turns, &p.Shortcuts)
+		case "combo":
+			_, err = fmt.Sscanf(value, "%d moves=%d turned=%t", &b.Scoring.combo, &b.Scoring.movesSinceCupcake, &b.Scoring.turned)
+		case "ticks":
+			_, err = fmt.Sscanf(value, "%d/%d skeleton=%d", &b.Ticks, &b.TicksPerMovement, &b.SkeleTicks)
+			haveTicks = true
+		case "direction":
+			i := strings.Index(boardHeadChars, value)
+			if len(value) != 1 || i < 0 {
+				err = fmt.Errorf("unknown direction %q", value)
+			}
+			b.Direction = snakeDirection(i + 1)
+			haveDirection = true
+		case "snake":
+			// boards from before smooth movement was recorded end at skeleton, with the snake at rest
+			var n int
+			n, err = fmt.Sscanf(value, "grow=%t mirrored=%t skeleton=%d moving=%t grew=%t", &snakeGrow, &snakeMirrored, &skeleton, &snakeMoving, &snakeGrew)
+			if n == 3 && errors.Is(err, io.ErrUnexpectedEOF) {
+				err = nil
+			}
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return b, fmt.Errorf("line %d: %s: %w", line, key, err)
+		}
+	}
+
+	// board, down to the bottom border
+	for sc.Scan() {
+		s := strings.TrimRight(sc.Text(), " \t\r")
+		rows = append(rows, s)
+		if strings.HasPrefix(s, "+") {
+			break
+		}
+	}
+	if len(rows) < 3 || !strings.HasPrefix(rows[len(rows)-1], "+") {
+		return b, errors.New("no board (expected a border of + - | ~ or # around it)")
+	}
+	b.Width = len(rows[0]) - 2
+	b.Height = len(rows) - 2
+	if b.Width < 1 {
+		return b, errors.New("board has no columns")
+	}
+	for i, row := range rows {
+		if len(row) != b.Width+2 {
+			return b, fmt.Errorf("board row %d is %d characters wide, expected %d", i, len(row), b.Width+2)
+		}
+	}
+
+	// the border must match the rules
+	g := b.game()
+	t := g.Topology()
+	top := boardBorderChars[t.y][0]
+	side := boardBorderChars[t.x][1]
+	border := "+" + strings.Repeat(string(top), b.Width) + "+"
+	for i, row := range rows {
+		switch {
+		case i == 0 || i == len(rows)-1:
+			if row != border {
+				return b, fmt.Errorf("board border %q, expected %q for the rules", row, border)
+			}
+		case row[0] != side || row[len(row)-1] != side:
+			return b, fmt.Errorf("board row %d has border %c...%c, expected %c...%c for the rules", i, row[0], row[len(row)-1], side, side)
+		}
+	}
+	cells := rows[1 : len(rows)-1]
+	at := func(x, y int) byte {
+		return cells[y][x+1]
+	}
+
+	// food & the snake's head
+	headX, headY := -1, -1
+	foodX, foodY := -1, -1
+	snakeCells := 0
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			c := at(x, y)
+			switch {
+			case c == '.':
+			case c == '*':
+				if foodX >= 0 {
+					return b, fmt.Errorf("more than one food, at %d,%d and %d,%d", foodX, foodY, x, y)
+				}
+				foodX, foodY = x, y
+			case strings.IndexByte(boardHeadChars, c) >= 0:
+				if headX >= 0 {
+					return b, fmt.Errorf("more than one snake head, at %d,%d and %d,%d", headX, headY, x, y)
+				}
+				headX, headY = x, y
+				snakeCells++
+			case strings.IndexByte(boardBodyChars, c) >= 0, strings.IndexByte(boardTailChars, c) >= 0:
+				snakeCells++
+			default:
+				return b, fmt.Errorf("unknown character %q at %d,%d", c, x, y)
+			}
+		}
+	}
+	if headX < 0 {
+		return b, errors.New("no snake head (U, D, L or R)")
+	}
+
+	// follow the snake back from its head, stepping the opposite way to the way it moved into each segment
+	segs := []SnakeBodySegment{{
+		x:        headX,
+		y:        headY,
+		facing:   snakeDirection(strings.IndexByte(boardHeadChars, at(headX, headY)) + 1),
+		mirrored: snakeMirrored,
+	}}
+	for {
+		seg := segs[len(segs)-1]
+		x, y, flipped, _ := g.NextPosition(seg.x, seg.y, OppositeDirection(seg.facing))
+		c := at(x, y)
+		d := strings.IndexByte(boardBodyChars, c)
+		tail := strings.IndexByte(boardTailChars, c)
+		if tail >= 0 {
+			d = tail
+		}
+		if d < 0 {
+			return b, fmt.Errorf("snake segment at %d,%d leads to %q at %d,%d, expected a body segment or tail", seg.x, seg.y, c, x, y)
+		}
+		if len(segs) == snakeCells {
+			return b, fmt.Errorf("snake has no tail, or loops back on itself at %d,%d", x, y)
+		}
+		segs = append(segs, SnakeBodySegment{
+			x:        x,
+			y:        y,
+			facing:   snakeDirection(d + 1),
+			mirrored: seg.mirrored != flipped,
+		})
+		if tail >= 0 {
+			break
+		}
+	}
+	if len(segs) != snakeCells {
+		return b, fmt.Errorf("snake is %d segments long, but there are %d snake segments on the board", len(segs), snakeCells)
+	}
+	if skeleton > len(segs) {
+		return b, fmt.Errorf("%d skeleton segments, but the snake is %d segments long", skeleton, len(segs))
+	}
+	for i := 0; i < skeleton; i++ {
+		segs[i].skeleton = true
+	}
+	s := SnakeBody{
+		segs:   make([]SnakeBodySegment, max(snakeInitialCapacity, len(segs))),
+		length: len(segs),
+		grow:   snakeGrow,
+		moving: snakeMoving,
+		grew:   snakeGrew,
+	}
+	copy(s.segs, segs)
+	b.Snake = &s
+
+	// no food: the snake has just eaten the last of it
+	b.Food = Food{x: headX, y: headY}
+	if foodX >= 0 {
+		b.Food = Food{x: foodX, y: foodY}
+	}
+
+	// missing tick counters & direction are as at the start of a game
+	if !haveTicks {
+		b.TicksPerMovement = g.Speed().first
+	}
+	if !haveDirection {
+		b.Direction = segs[0].facing
+	}
+	return b, nil
+}
+
+// save the board as text to the captures directory (F8), for bug reports
+// (while a game is being played or watched)
+func (g *Game) DumpBoard() {
+	src := g
+	switch g.state {
+	case StateGameStart, StateInGame, StateGameEnd, StateGameOver, StateGameWon, StatePaused:
+	case StateReplayView:
+		src = g.viewer.player.g
+	default:
+		return
+	}
+
+	r := g.capture
+	dir, err := g.CaptureDir()
+	if err != nil {
+		r.message = g.T("capture.failed", err)
+		r.messageTicks = captureMessageTicks
+		return
+	}
+	name := filepath.Join(dir, fmt.Sprintf("snake-%s.txt", time.Now().Format("20060102-150405.000")))
+	b := src.Board()
+	if b.State == StatePaused {
+		b.State = StateInGame
+	}
+	err = os.WriteFile(name, []byte(b.String()), 0o644)
+	if err != nil {
+		r.message = g.T("capture.failed", err)
+	} else {
+		r.message = g.T("capture.saved", filepath.Base(name))
+	}
+	r.messageTicks = captureMessageTicks
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// the example board in Board's doc comment
+const exampleBoard = `rules: {"walls":true}
+seed: 1
+state: ingame
+score: 1
+points: cupcakes=200 combo=0 length=0 turns=0 shortcuts=0
+combo: 1 moves=3 turned=false
+ticks: 12/39 skeleton=0
+direction: L
+snake: grow=false mirrored=false skeleton=0 moving=true grew=false
++######+
+#......#
+#.*....#
+#..L<^.#
+#....^.#
+#....u.#
++######+
+`
+
+// parsing a board gives the game it describes
+func TestParseBoard(t *testing.T) {
+	b, err := ParseBoard(exampleBoard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Width != 6 || b.Height != 5 || !b.Rules.Walls || b.Seed != 1 || b.State != StateInGame || b.Direction != LEFT {
+		t.Fatalf("parsed %dx%d rules %+v seed %d state %d direction %d", b.Width, b.Height, b.Rules, b.Seed, b.State, b.Direction)
+	}
+	if b.Food != (Food{1, 1}) || b.Score != 1 || b.Scoring.Breakdown.Cupcakes != 200 || b.Scoring.movesSinceCupcake != 3 {
+		t.Fatalf("parsed food %+v score %d scoring %+v", b.Food, b.Score, b.Scoring)
+	}
+	if b.Ticks != 12 || b.TicksPerMovement != 39 {
+		t.Fatalf("parsed ticks %d/%d", b.Ticks, b.TicksPerMovement)
+	}
+	want := []SnakeBodySegment{
+		{x: 2, y: 2, facing: LEFT},
+		{x: 3, y: 2, facing: LEFT},
+		{x: 4, y: 2, facing: UP},
+		{x: 4, y: 3, facing: UP},
+		{x: 4, y: 4, facing: UP},
+	}
+	if b.Snake.length != len(want) {
+		t.Fatalf("parsed snake of length %d, want %d", b.Snake.length, len(want))
+	}
+	for i, seg := range want {
+		if *b.Snake.Segment(i) != seg {
+			t.Fatalf("parsed segment %d %+v, want %+v", i, *b.Snake.Segment(i), seg)
+		}
+	}
+}
+
+// boards of games in many states print & parse back the same
+func TestBoardRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		rules Rules
+		moves string
+		ticks int
+	}{
+		{Rules{}, "", 0},
+		{Rules{}, "UUUUULLLLLLDDDRRR", 10},
+		{Rules{Walls: true}, "UUUUU", 6},
+		{Rules{Topology: "cylinder", TailBlocks: true}, "LLLLLLLUUU", 0},
+		{Rules{Topology: "klein"}, "UUUUULLD", 0},
+		{Rules{Topology: "projective", Speed: "fast"}, "LLLLLLDDRRRRUUUUUU", 0},
+	} {
+		h := NewHarness(t, 10, 8, 3, test.rules)
+		h.Play(test.moves)
+		h.RunTo(h.tick + test.ticks)
+		text := h.g.Board().String()
+
+		b, err := ParseBoard(text)
+		if err != nil {
+			t.Fatalf("%s\n%v", text, err)
+		}
+		if got := b.String(); got != text {
+			t.Fatalf("board printed\n%s\nparsed & printed again\n%s", text, got)
+		}
+		s := h.g.SnakeBody
+		for i := 0; i < s.length; i++ {
+			if *b.Snake.Segment(i) != *s.Segment(i) {
+				t.Fatalf("%s\nparsed segment %d %+v, want %+v", text, i, *b.Snake.Segment(i), *s.Segment(i))
+			}
+		}
+	}
+}
+
+// a game played on from a parsed board plays on as the game it came from
+func TestBoardGamePlaysOn(t *testing.T) {
+	h := NewHarness(t, 10, 8, 5, Rules{})
+	h.Play("UULLL")
+	g, err := NewBoardGame(h.g.Board())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2 := &Harness{t: t, g: g, script: make(map[int]snakeDirection)}
+	for _, d := range "DDDRRRRRRUUUU" {
+		h.Play(string(d))
+		h2.Play(string(d))
+		h2.AssertBoard(h.g.Board().String())
+	}
+}
+
+// boards as fixtures: a snake crossing the top edge of a Klein bottle comes back on the other side, mirrored
+// (and scores a shortcut, as it comes back nearer the food)
+func TestBoardFixtureKlein(t *testing.T) {
+	h := NewHarnessFromBoard(t, `rules: {"topology":"klein"}
++~~~~~~+
+|..U...|
+|..^...|
+|..u...|
+|.....*|
++~~~~~~+
+`)
+	h.Play("U")
+	h.AssertBoard(`rules: {"topology":"klein"}
+seed: 0
+state: ingame
+score: 0
+points: cupcakes=0 combo=0 length=0 turns=0 shortcuts=50
+combo: 0 moves=1 turned=false
+ticks: 0/40 skeleton=0
+direction: U
+snake: grow=false mirrored=true skeleton=0 moving=true grew=false
++~~~~~~+
+|..^...|
+|..u...|
+|......|
+|...U.*|
++~~~~~~+
+`)
+}
+
+// boards saved before the snake line recorded smooth movement parse, with the snake at rest
+func TestParseBoardOldSnakeLine(t *testing.T) {
+	b, err := ParseBoard(strings.Replace(exampleBoard, " moving=true grew=false", "", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Snake.moving || b.Snake.grew || b.Snake.length != 5 {
+		t.Fatalf("parsed snake of length %d moving %v grew %v, want 5, at rest", b.Snake.length, b.Snake.moving, b.Snake.grew)
+	}
+}
+
+// boards that don't make sense are rejected
+func TestParseBoardErrors(t *testing.T) {
+	for _, test := range []struct {
+		board, err string
+	}{
+		{"seed: 1\n", "no board"},
+		{"colour: blue\n+--+\n|U.|\n|u.|\n+--+\n", "unknown key"},
+		{"+--+\n|..|\n|..|\n+--+\n", "no snake head"},
+		{"+--+\n|UU|\n|uu|\n+--+\n", "more than one snake head"},
+		{"+--+\n|U*|\n|u*|\n+--+\n", "more than one food"},
+		{"+--+\n|U.|\n|..|\n+--+\n", "expected a body segment or tail"},
+		{"+--+\n|U.|\n|u|\n+--+\n", "characters wide"},
+		{"+##+\n|U.|\n|u.|\n+##+\n", "board border"},
+		{"+--+\n#U.#\n#u.#\n+--+\n", "board row 1 has border"},
+		{"+--+\n|U.|\n|uv|\n+--+\n", "segments on the board"},
+		{"+--+\n|Ux|\n|u.|\n+--+\n", "unknown character"},
+		{"+--+\n|U.|\n|<^|\n|v>|\n+--+\n", "no tail, or loops"},
+	} {
+		_, err := ParseBoard(test.board)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("parsing\n%s\nerror %v, want %q", test.board, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+)
+
+// fraction of the way the camera moves towards the snake's head each tick
+const cameraFollow = 0.1
+
+// width of the minimap, as a fraction of the view's width
+const minimapFraction = 0.25
+
+// a world size: the board size for normal games, as a multiple of the usual board size
+type WorldSize struct {
+
+	// settings value for the world size
+	id string
+
+	// message key of the world size's name
+	name string
+
+	// multiple of the usual board size
+	scale int
+}
+
+// available world sizes, the screen sized board first
+var worldSizes = []WorldSize{
+	{"", "world.normal", 1},
+	{"large", "world.large", 2},
+	{"huge", "world.huge", 3},
+}
+
+// return the world size chosen in the settings
+func (g *Game) WorldSize() WorldSize {
+	for _, s := range worldSizes {
+		if s.id == g.settings.WorldSize {
+			return s
+		}
+	}
+	return worldSizes[0]
+}
+
+// camera following the snake's head around boards bigger than the view
+type Camera struct {
+
+	// pixel position on the board of the top left of the view
+	x, y float64
+}
+
+// return the size of the view of the board, in tiles: the whole board, up to the usual board size
+// (bigger boards scroll to follow the snake's head)
+func (g *Game) ViewSize() (w, h int) {
+	return min(g.width, g.defaultWidth), min(g.height, g.defaultHeight)
+}
+
+// does the board scroll (is it bigger than the view)?
+func (g *Game) Scrolls() bool {
+	w, h := g.ViewSize()
+	return w < g.width || h < g.height
+}
+
+// return where the camera should be: centred on the snake's head, but not past the edges of the board
+func (g *Game) CameraTarget() (x, y float64) {
+	head := g.SnakeBody.Head()
+	x = float64(head.x * TILESIZE)
+	y = float64(head.y * TILESIZE)
+
+	// follow the head as it slides between cells
+	progress := g.MovementProgress()
+	dx, dy := DirectionDelta(head.facing)
+	x -= float64(dx*TILESIZE) * (1 - progress)
+	y -= float64(dy*TILESIZE) * (1 - progress)
+
+	w, h := g.ViewSize()
+	x = math.Min(math.Max(x-float64((w*TILESIZE-TILESIZE)/2), 0), float64((g.width-w)*TILESIZE))
+	y = math.Min(math.Max(y-float64((h*TILESIZE-TILESIZE)/2), 0), float64((g.height-h)*TILESIZE))
+	return x, y
+}
+
+// move the camera part of the way towards the snake's head
+// if the head has jumped (wrapped around the edge of the board, or a replay has skipped), the camera cuts straight to it
+func (g *Game) UpdateCamera() {
+	x, y := g.CameraTarget()
+	w, h := g.ViewSize()
+	if math.Abs(x-g.camera.x) > float64(w*TILESIZE/2) || math.Abs(y-g.camera.y) > float64(h*TILESIZE/2) {
+		g.camera = Camera{x, y}
+		return
+	}
+	g.camera.x += (x - g.camera.x) * cameraFollow
+	g.camera.y += (y - g.camera.y) * cameraFollow
+}
+
+// move the camera straight to the snake's head
+func (g *Game) ResetCamera() {
+	g.camera.x, g.camera.y = g.CameraTarget()
+}
@@ -0,0 +1,76 @@
+//go:build !headless
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// clear & return the offscreen image the whole board is drawn to, before the camera's view of it is drawn
+func (g *Game) World() *ebiten.Image {
+	g.world.Clear()
+	return g.world
+}
+
+// draw the camera's view of the board (drawn to World()), offsetting by yOffset (for score bar),
+// with a minimap if the board is bigger than the view
+func (g *Game) DrawView(imgOut *ebiten.Image, yOffset int) {
+	w, h := g.ViewSize()
+	x, y := int(math.Round(g.camera.x)), int(math.Round(g.camera.y))
+	view := g.world.SubImage(image.Rect(x, y, x+w*TILESIZE, y+h*TILESIZE)).(*ebiten.Image)
+	op := ebiten.DrawImageOptions{}
+	op.GeoM.Translate(0, float64(yOffset))
+	imgOut.DrawImage(view, &op)
+	if g.Scrolls() {
+		g.DrawMinimap(imgOut, yOffset)
+	}
+}
+
+// draw a map of the whole board in the top right corner of the view: the food, the snakes
+// (and the ghost's), and the part of the board in view
+func (g *Game) DrawMinimap(imgOut *ebiten.Image, yOffset int) {
+	w, h := g.ViewSize()
+	scale := float32(float64(w*TILESIZE) * minimapFraction / float64(g.width))
+	mw, mh := scale*float32(g.width), scale*float32(g.height)
+	mx := float32(w*TILESIZE) - mw - 4
+	my := float32(yOffset) + 4
+	vector.DrawFilledRect(imgOut, mx-1, my-1, mw+2, mh+2, fadeColour(g.PaletteColour(colourDark), 0.85), false)
+
+	// a cell of the board
+	cell := func(x, y int, c color.RGBA, alpha float64) {
+		vector.DrawFilledRect(imgOut, mx+scale*float32(x), my+scale*float32(y), max(scale, 1), max(scale, 1), fadeColour(g.PaletteColour(c), alpha), false)
+	}
+
+	// the ghost, faded
+	if g.ghost != nil {
+		sim := g.ghost.g
+		cell(sim.food.x, sim.food.y, colourIcing, 0.4)
+		for i := 0; i < sim.SnakeBody.length; i++ {
+			seg := sim.SnakeBody.Segment(i)
+			cell(seg.x, seg.y, colourSnakeGreen, 0.4)
+		}
+	}
+
+	// food & snakes, heads highlighted
+	if g.state != StateGameWon {
+		cell(g.food.x, g.food.y, colourIcing, 1)
+	}
+	for _, s := range g.Snakes() {
+		for i := s.length - 1; i >= 0; i-- {
+			seg := s.Segment(i)
+			c := colourSnakeGreen
+			if i == 0 {
+				c = colourSnakeYellow
+			}
+			cell(seg.x, seg.y, c, 1)
+		}
+	}
+
+	// the view
+	vector.StrokeRect(imgOut, mx+scale*float32(g.camera.x/TILESIZE), my+scale*float32(g.camera.y/TILESIZE), scale*float32(w), scale*float32(h), 1, g.PaletteColour(textHighlightColour), false)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// capture a frame for the rolling GIF buffer every this many ticks (60/3 = 20 frames per second)
+const captureEveryTicks = 3
+
+// ticks a capture message is shown for
+const captureMessageTicks = 150
+
+// screenshot & GIF capture state
+type Recorder struct {
+
+	// rolling buffer of recent frames, oldest first once full, and their size (the same for every frame in a GIF)
+	frames []*image.RGBA
+	bounds image.Rectangle
+
+	// index of the slot the next frame is captured into
+	next int
+
+	// ticks since the last frame was captured
+	ticks int
+
+	// captures to take on the next draw
+	frameDue      bool
+	screenshotDue bool
+	gifDue        bool
+
+	// results from saving captures in the background, and saves not yet finished
+	results chan captureResult
+	saving  sync.WaitGroup
+
+	// message shown on screen after a capture is saved
+	message      string
+	messageTicks int
+}
+
+// result of saving a capture
+type captureResult struct {
+
+	// file saved
+	name string
+
+	// error saving, if any
+	err error
+}
+
+// return a new recorder
+func NewRecorder() *Recorder {
+	return &Recorder{
+		results: make(chan captureResult, 4),
+	}
+}
+
+// return the directory captures are saved to, creating it if needed
+func (g *Game) CaptureDir() (string, error) {
+	dir := g.settings.CaptureDir
+	if dir == "" {
+		var err error
+		dir, err = DataPath("captures")
+		if err != nil {
+			return "", err
+		}
+	}
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// save a capture to a new file in directory dir, with encode writing the file
+// called in the background: the result is reported through the results channel
+func (r *Recorder) Save(dir, ext string, encode func(f *os.File) error) {
+	name := filepath.Join(dir, fmt.Sprintf("snake-%s.%s", time.Now().Format("20060102-150405.000"), ext))
+	err := func() error {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		err = encode(f)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}()
+	r.saving.Done()
+	r.results <- captureResult{name: filepath.Base(name), err: err}
+}
+
+// wait for captures being saved in the background to finish
+func (r *Recorder) Wait() {
+	r.saving.Wait()
+}
+
+// build an animated GIF from frames, with delay (in 100ths of a second) between frames
+// the palette is the 256 most common colours across all frames, with other colours mapped to the nearest of those
+func EncodeGIF(frames []*image.RGBA, delay int) *gif.GIF {
+
+	// count colours
+	counts := make(map[color.RGBA]int)
+	for _, f := range frames {
+		for i := 0; i < len(f.Pix); i += 4 {
+			counts[color.RGBA{f.Pix[i], f.Pix[i+1], f.Pix[i+2], 255}]++
+		}
+	}
+
+	// palette of most common colours
+	colours := make([]color.RGBA, 0, len(counts))
+	for c := range counts {
+		colours = append(colours, c)
+	}
+	sort.Slice(colours, func(i, j int) bool {
+		return counts[colours[i]] > counts[colours[j]]
+	})
+	pal := make(color.Palette, 0, 256)
+	for _, c := range colours {
+		if len(pal) == 256 {
+			break
+		}
+		pal = append(pal, c)
+	}
+
+	// palette index of every colour used
+	index := make(map[color.RGBA]uint8, len(colours))
+	for _, c := range colours {
+		index[c] = uint8(pal.Index(c))
+	}
+
+	// convert frames
+	anim := gif.GIF{}
+	for _, f := range frames {
+		p := image.NewPaletted(f.Bounds(), pal)
+		for i := 0; i < len(f.Pix); i += 4 {
+			p.Pix[i/4] = index[color.RGBA{f.Pix[i], f.Pix[i+1], f.Pix[i+2], 255}]
+		}
+		anim.Image = append(anim.Image, p)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	return &anim
+}
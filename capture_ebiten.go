@@ -0,0 +1,137 @@
+//go:build !headless
+
+package main
+
+import (
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// update capture state: handle capture keys, and collect results of background saves
+func (g *Game) UpdateCapture() {
+	r := g.capture
+
+	// F12 saves a screenshot, F9 saves the last few seconds as a GIF
+	if inpututil.IsKeyJustPressed(ebiten.KeyF12) {
+		r.screenshotDue = true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		r.gifDue = true
+	}
+
+	// F8 saves the board as text
+	if inpututil.IsKeyJustPressed(ebiten.KeyF8) {
+		g.DumpBoard()
+	}
+
+	// rolling buffer frame rate
+	r.ticks++
+	if r.ticks >= captureEveryTicks && g.settings.GIFSeconds > 0 {
+		r.ticks = 0
+		r.frameDue = true
+	}
+
+	// results of background saves
+	select {
+	case res := <-r.results:
+		if res.err != nil {
+			r.message = g.T("capture.failed", res.err)
+		} else {
+			r.message = g.T("capture.saved", res.name)
+		}
+		r.messageTicks = captureMessageTicks
+	default:
+	}
+	if r.messageTicks > 0 {
+		r.messageTicks--
+	}
+}
+
+// take any due captures of frame, called once the frame has been drawn
+func (g *Game) CaptureFrame(frame *ebiten.Image) {
+	r := g.capture
+
+	// rolling buffer
+	if r.frameDue {
+		r.frameDue = false
+
+		// a new buffer if the frame has changed size (daily challenge & replay boards differ in size)
+		size := g.settings.GIFSeconds * ebiten.TPS() / captureEveryTicks
+		if len(r.frames) != size || r.bounds != frame.Bounds() {
+			r.frames = make([]*image.RGBA, size)
+			r.next = 0
+			r.bounds = frame.Bounds()
+		}
+		img := r.frames[r.next]
+		if img == nil {
+			img = image.NewRGBA(frame.Bounds())
+			r.frames[r.next] = img
+		}
+		frame.ReadPixels(img.Pix)
+		r.next = (r.next + 1) % len(r.frames)
+	}
+
+	// saved captures need somewhere to go
+	if !r.screenshotDue && !r.gifDue {
+		return
+	}
+	dir, err := g.CaptureDir()
+	if err != nil {
+		r.screenshotDue = false
+		r.gifDue = false
+		r.message = g.T("capture.failed", err)
+		r.messageTicks = captureMessageTicks
+		return
+	}
+
+	// screenshot
+	if r.screenshotDue {
+		r.screenshotDue = false
+		img := image.NewRGBA(frame.Bounds())
+		frame.ReadPixels(img.Pix)
+		r.saving.Add(1)
+		go r.Save(dir, "png", func(f *os.File) error {
+			return png.Encode(f, img)
+		})
+	}
+
+	// GIF of rolling buffer: hand over the buffered frames (oldest first) and start a new buffer
+	if r.gifDue {
+		r.gifDue = false
+		var frames []*image.RGBA
+		for i := range r.frames {
+			img := r.frames[(r.next+i)%len(r.frames)]
+			if img != nil {
+				frames = append(frames, img)
+			}
+		}
+		r.frames = nil
+		if len(frames) == 0 {
+			r.message = g.T("capture.nothing")
+			r.messageTicks = captureMessageTicks
+			return
+		}
+		r.saving.Add(1)
+		go r.Save(dir, "gif", func(f *os.File) error {
+			return gif.EncodeAll(f, EncodeGIF(frames, captureEveryTicks*100/ebiten.TPS()))
+		})
+	}
+}
+
+// draw the capture message (if any)
+func (g *Game) DrawCaptureMessage(imgOut *ebiten.Image) {
+	r := g.capture
+	if r.messageTicks <= 0 {
+		return
+	}
+	w, h := g.ScreenSize()
+	g.DrawText(imgOut, r.message, 4, float64(h-FONTSIZE-4), TextStyle{
+		Outline:   textOutlineColour,
+		WrapWidth: float64(w - 8),
+	})
+}
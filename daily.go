@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// daily challenge results file, within the data directory
+const dailyFile = "daily.json"
+
+// board sizes a daily challenge can be played on
+// (no larger than the usual board, so the game fits the window)
+var dailyBoardSizes = [][2]int{
+	{27, 20},
+	{25, 19},
+	{23, 17},
+	{21, 16},
+	{19, 15},
+}
+
+// a daily challenge: the same game for everyone on a given day
+type Daily struct {
+
+	// date of the challenge (UTC), as YYYY-MM-DD
+	Date string
+
+	// random number generator seed (food placement)
+	Seed uint64
+
+	// size of game board
+	Width, Height int
+
+	// rules of the game
+	Rules Rules
+}
+
+// the best result of a day's challenge
+type DailyResult struct {
+
+	// best score (cupcakes eaten), and its points (calories)
+	Score  int `json:"score"`
+	Points int `json:"points,omitempty"`
+
+	// replay of the best game
+	Replay *Replay `json:"replay"`
+}
+
+// daily challenge results, by date (YYYY-MM-DD)
+type DailyResults map[string]DailyResult
+
+// return the daily challenge for the day (UTC) of time t
+// everything is derived from the date, so everyone gets the same challenge on the same day
+func DailyChallenge(t time.Time) Daily {
+	date := t.UTC().Format(time.DateOnly)
+	h := fnv.New64a()
+	h.Write([]byte("snake daily " + date))
+	seed := h.Sum64()
+
+	// board size & rules come from their own random sequence of the seed
+	rng := NewRNG(seed, 1<<63)
+	size := dailyBoardSizes[rng.Intn(len(dailyBoardSizes))]
+	return Daily{
+		Date:   date,
+		Seed:   seed,
+		Width:  size[0],
+		Height: size[1],
+		Rules: Rules{
+			Walls: rng.Intn(2) == 0,
+			Speed: speedPresets[rng.Intn(len(speedPresets))].id,
+		},
+	}
+}
+
+// load daily challenge results from the data directory
+// if there is no results file, no results are returned
+func LoadDailyResults() (DailyResults, error) {
+	results := make(DailyResults)
+	path, err := DataPath(dailyFile)
+	if err != nil {
+		return results, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return results, nil
+	}
+	if err != nil {
+		return results, err
+	}
+	err = json.Unmarshal(b, &results)
+	if err != nil {
+		return make(DailyResults), fmt.Errorf("%s: %w", path, err)
+	}
+	return results, nil
+}
+
+// save daily challenge results to the data directory
+func (d DailyResults) Save() error {
+	path, err := DataPath(dailyFile)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// return the number of consecutive days with a result, up to date (YYYY-MM-DD)
+// today not having been played yet doesn't break the streak
+func (d DailyResults) Streak(date string) int {
+	day, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return 0
+	}
+	if _, ok := d[date]; !ok {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for {
+		if _, ok := d[day.Format(time.DateOnly)]; !ok {
+			return streak
+		}
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+}
+
+// record replay r of the daily challenge being played, if it is the day's best
+func (g *Game) RecordDailyResult(r *Replay) {
+	if res, ok := g.dailyResults[g.daily.Date]; ok && res.Points >= r.Points {
+		return
+	}
+	g.dailyResults[g.daily.Date] = DailyResult{Score: r.Score, Points: r.Points, Replay: r}
+	err := g.dailyResults.Save()
+	if err != nil {
+		log.Printf("could not save daily challenge results: %v", err)
+	}
+}
+
+// return a short text describing the day's daily challenge result, for sharing
+func (g *Game) DailyShareText() string {
+	d := g.daily
+	edges := g.T("daily.wrap")
+	if d.Rules.Walls {
+		edges = g.T("daily.walls")
+	}
+	return g.T("daily.share",
+		d.Date,
+		d.Width, d.Height, edges, g.T(g.Speed().name),
+		g.dailyResults[d.Date].Points,
+		g.dailyResults.Streak(d.Date),
+	)
+}
+
+// save the day's daily challenge result as a text file for sharing, alongside screenshots
+func (g *Game) ShareDailyResult() {
+	r := g.capture
+	dir, err := g.CaptureDir()
+	if err != nil {
+		r.message = g.T("capture.failed", err)
+		r.messageTicks = captureMessageTicks
+		return
+	}
+	name := filepath.Join(dir, fmt.Sprintf("snake-daily-%s.txt", g.daily.Date))
+	err = os.WriteFile(name, []byte(g.DailyShareText()+"\n"), 0o644)
+	if err != nil {
+		r.message = g.T("capture.failed", err)
+	} else {
+		r.message = g.T("capture.saved", filepath.Base(name))
+	}
+	r.messageTicks = captureMessageTicks
+}
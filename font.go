@@ -0,0 +1,41 @@
+package main
+
+import (
+	_ "embed"
+	"image/color"
+)
+
+// Embedded fonts
+var (
+	//go:embed assets/fonts/pressstart2p.ttf
+	ttfPressStart2P []byte
+
+	//go:embed assets/fonts/mplus-1p-regular.ttf
+	ttfMPlus1p []byte
+)
+
+// Font size in pixels (the primary font is designed for multiples of 8)
+const FONTSIZE = 8
+
+// Fallback font size in pixels for languages whose scripts need more pixels to be legible
+var fallbackFontSizes = map[string]float64{
+	"ja": 12,
+}
+
+// Spacing between lines of text in pixels
+const LINESPACING = 12
+
+// colours for text
+var (
+	// normal text
+	textColour = color.RGBA{255, 255, 255, 255}
+
+	// highlighted text (selected menu items, headings)
+	textHighlightColour = color.RGBA{251, 242, 54, 255}
+
+	// less important text (descriptions)
+	textDimColour = color.RGBA{170, 170, 170, 255}
+
+	// text outline
+	textOutlineColour = color.RGBA{34, 32, 52, 255}
+)
@@ -0,0 +1,165 @@
+//go:build !headless
+
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// how a piece of text should be drawn
+type TextStyle struct {
+
+	// horizontal alignment of the text relative to x
+	Align text.Align
+
+	// colour of the text (nil for the default text colour)
+	Colour color.Color
+
+	// colour of the text outline (nil for no outline)
+	Outline color.Color
+
+	// wrap the text to this width in pixels (0 for no wrapping)
+	WrapWidth float64
+}
+
+// parsed UI fonts, shared by all faces
+type FontSources struct {
+
+	// pixel font for latin scripts
+	primary *text.GoTextFaceSource
+
+	// fallback for glyphs missing from the primary font (covers non-latin scripts such as Japanese)
+	fallback *text.GoTextFaceSource
+}
+
+// load the UI fonts, called once on startup
+func LoadFontSources() (*FontSources, error) {
+	primary, err := text.NewGoTextFaceSource(bytes.NewReader(ttfPressStart2P))
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := text.NewGoTextFaceSource(bytes.NewReader(ttfMPlus1p))
+	if err != nil {
+		return nil, err
+	}
+	return &FontSources{primary: primary, fallback: fallback}, nil
+}
+
+// return the UI font face for text in language lang
+func (f *FontSources) Face(lang string) (text.Face, error) {
+	size, ok := fallbackFontSizes[lang]
+	if !ok {
+		size = FONTSIZE
+	}
+	return text.NewMultiFace(
+		&text.GoTextFace{Source: f.primary, Size: FONTSIZE},
+		&text.GoTextFace{Source: f.fallback, Size: size},
+	)
+}
+
+// return the width of txt in pixels
+func (g *Game) TextWidth(txt string) float64 {
+	w, _ := text.Measure(txt, g.font, LINESPACING)
+	return w
+}
+
+// split txt into lines no wider than width pixels, breaking between words
+func (g *Game) WrapText(txt string, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(txt, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			switch {
+			case line == "":
+				line = word
+			case g.TextWidth(line+" "+word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// draw txt with its top edge at y, aligned against x according to style
+// returns the height of the drawn text in pixels
+func (g *Game) DrawText(imgOut *ebiten.Image, txt string, x, y float64, style TextStyle) float64 {
+	if style.WrapWidth > 0 {
+		txt = strings.Join(g.WrapText(txt, style.WrapWidth), "\n")
+	}
+	if style.Colour == nil {
+		style.Colour = textColour
+	}
+	if c, ok := style.Colour.(color.RGBA); ok {
+		style.Colour = g.PaletteColour(c)
+	}
+	if c, ok := style.Outline.(color.RGBA); ok {
+		style.Outline = g.PaletteColour(c)
+	}
+
+	op := text.DrawOptions{}
+	op.PrimaryAlign = style.Align
+	op.LineSpacing = LINESPACING
+
+	// outline: draw the text offset by a pixel in each direction underneath
+	if style.Outline != nil {
+		for _, d := range [][2]float64{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}} {
+			op.GeoM.Reset()
+			op.ColorScale.Reset()
+			op.GeoM.Translate(x+d[0], y+d[1])
+			op.ColorScale.ScaleWithColor(style.Outline)
+			text.Draw(imgOut, txt, g.font, &op)
+		}
+	}
+
+	op.GeoM.Reset()
+	op.ColorScale.Reset()
+	op.GeoM.Translate(x, y)
+	op.ColorScale.ScaleWithColor(style.Colour)
+	text.Draw(imgOut, txt, g.font, &op)
+
+	_, h := text.Measure(txt, g.font, LINESPACING)
+	return h
+}
+
+// draw txt centred across the screen with its top edge at y
+// returns the height of the drawn text in pixels
+func (g *Game) DrawTextCentred(imgOut *ebiten.Image, txt string, y float64, style TextStyle) float64 {
+	style.Align = text.AlignCenter
+	w, _ := g.ScreenSize()
+	return g.DrawText(imgOut, txt, float64(w)/2, y, style)
+}
+
+// draw a block of key help lines ("KEY: description") centred across the screen, with the colons lined up
+// returns the height of the drawn block in pixels
+func (g *Game) DrawKeyHelp(imgOut *ebiten.Image, y float64, keys [][2]string, style TextStyle) float64 {
+
+	// find widest key & description, so the whole block can be centred
+	var keyWidth, descWidth float64
+	for _, k := range keys {
+		keyWidth = max(keyWidth, g.TextWidth(k[0]+": "))
+		descWidth = max(descWidth, g.TextWidth(k[1]))
+	}
+	w, _ := g.ScreenSize()
+	x := (float64(w)-keyWidth-descWidth)/2 + keyWidth
+
+	// keys right aligned against descriptions
+	keyStyle := style
+	keyStyle.Align = text.AlignEnd
+	descStyle := style
+	descStyle.Align = text.AlignStart
+	for i, k := range keys {
+		ky := y + float64(i)*LINESPACING*1.25
+		g.DrawText(imgOut, k[0]+": ", x, ky, keyStyle)
+		g.DrawText(imgOut, k[1], x, ky, descStyle)
+	}
+	return float64(len(keys)) * LINESPACING * 1.25
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+)
+
+// find the best (highest scoring) saved replay for the board size, for the ghost to replay
+func (g *Game) LoadBestReplay() {
+	files, err := LoadReplayFiles()
+	if err != nil {
+		log.Printf("could not load replays: %v", err)
+		return
+	}
+	g.best = nil
+	for _, f := range files {
+		g.UpdateBestReplay(f.replay)
+	}
+}
+
+// make replay r the best replay, if it is for the usual board size & rules, and beats the current best
+func (g *Game) UpdateBestReplay(r *Replay) {
+	if r.Width != g.defaultWidth || r.Height != g.defaultHeight || r.Rules != (Rules{}) {
+		return
+	}
+	if g.best == nil || r.Points > g.best.Points {
+		g.best = r
+	}
+}
+
+// return a copy of the game for playing back a replay on, sharing its images, fonts & settings
+func (g *Game) PlaybackGame() *Game {
+	sim := *g
+	sim.particles = nil
+	sim.scorePopups = nil
+	sim.tongueShow = false
+	sim.viewer = nil
+	sim.ghostMode = false
+	sim.ghost = nil
+	sim.best = nil
+	sim.achievements = nil
+	return &sim
+}
+
+// start the ghost replaying replay r, in step with the game just started
+func (g *Game) StartGhost(r *Replay) {
+	var err error
+
+	// the ghost has no effects of its own
+	sim := g.PlaybackGame()
+	sim.settings.Particles = false
+	sim.settings.ScorePopups = false
+	sim.settings.ScreenShake = false
+
+	g.ghost, err = NewReplayPlayer(sim, r)
+	if err != nil {
+		log.Printf("could not start ghost: %v", err)
+		g.ghost = nil
+	}
+}
+
+// advance the ghost by one tick
+func (g *Game) UpdateGhost() {
+	if g.ghost == nil {
+		return
+	}
+	g.ghost.Step()
+	g.ghost.g.RandomSnakeTongue()
+}
@@ -0,0 +1,32 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// draw the ghost's food & snake (faded, behind the player's), offsetting by yOffset (for score bar)
+func (g *Game) DrawGhost(imgOut *ebiten.Image, yOffset int) {
+	if g.ghost == nil {
+		return
+	}
+	sim := g.ghost.g
+	sim.DrawFood(imgOut, yOffset, true)
+	sim.DrawSnake(sim.SnakeBody, imgOut, yOffset, true, sim.MovementProgress())
+}
+
+// draw how far ahead of (or behind) the ghost the player is, in calories at the same tick, at the right of the score bar
+func (g *Game) DrawGhostIndicator(imgOut *ebiten.Image) {
+	if g.ghost == nil {
+		return
+	}
+	diff := g.scoring.Breakdown.Total() - g.ghost.g.scoring.Breakdown.Total()
+	style := TextStyle{Align: text.AlignEnd}
+	if diff > 0 {
+		style.Colour = textHighlightColour
+	}
+	w := g.scoreBar.Bounds().Dx()
+	g.DrawText(imgOut, g.T("ghost.diff", diff), float64(w-4), float64(g.scoreBar.Bounds().Dy()-FONTSIZE)/2, style)
+}
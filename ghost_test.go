@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+)
+
+// racing the ghost: the countdown ends in game, with the ghost replaying the best replay in step
+func TestGhostRace(t *testing.T) {
+	best := NewHarness(t, 10, 8, 4, Rules{})
+	best.Play("UUULLLDDDRRRR")
+
+	h := NewGhostHarness(t, best.g.replay)
+	g := h.g
+	h.AssertState(StateInGame)
+	if g.seed != best.g.replay.Seed {
+		t.Fatalf("racing the ghost with seed %d, want the best replay's seed %d", g.seed, best.g.replay.Seed)
+	}
+	if g.ghost == nil {
+		t.Fatal("no ghost")
+	}
+	if g.ghost.g.ghost != nil || g.ghost.g.ghostMode {
+		t.Fatal("the ghost is racing a ghost of its own")
+	}
+
+	// the ghost plays the best replay's moves, tick for tick
+	for h.tick < best.tick {
+		h.Step()
+		if g.ghost.tick != h.tick {
+			t.Fatalf("tick %d: ghost at tick %d", h.tick, g.ghost.tick)
+		}
+	}
+	if got, want := g.ghost.g.Board().String(), best.g.Board().String(); got != want {
+		t.Fatalf("tick %d: ghost's board\n%s\nwant\n%s", h.tick, got, want)
+	}
+}
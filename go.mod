@@ -2,13 +2,18 @@ module github.com/mikenye/snake
 
 go 1.22.0
 
-require github.com/hajimehoshi/ebiten/v2 v2.7.3
+require (
+	github.com/hajimehoshi/ebiten/v2 v2.7.3
+	golang.org/x/image v0.15.0
+)
 
 require (
 	github.com/ebitengine/gomobile v0.0.0-20240329170434-1771503ff0a8 // indirect
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
 	github.com/ebitengine/purego v0.7.0 // indirect
+	github.com/go-text/typesetting v0.1.1-0.20240325125605-c7936fe59984 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
 	golang.org/x/sync v0.6.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 )
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// go test -update rewrites the golden frames in testdata/golden from the frames rendered
+var updateGolden = flag.Bool("update", false, "rewrite golden frames")
+
+// a headless game played by a script: a fixed seed, and input at given ticks
+// ticks are counted from the start of StateInGame, as in replays
+type Harness struct {
+	t *testing.T
+
+	// the game being played
+	g *Game
+
+	// ticks played so far
+	tick int
+
+	// direction to steer in at each tick there is input
+	script map[int]snakeDirection
+}
+
+// return a new harness playing a game on a board of width x height, with the given seed & rules
+// the game starts in StateInGame, with the snake in the middle of the board facing up
+func NewHarness(t *testing.T, width, height int, seed uint64, rules Rules) *Harness {
+	t.Helper()
+	g, err := NewHeadlessGame(width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.rules = rules
+	g.Reset(seed)
+	g.ChangeState(StateInGame)
+	return &Harness{t: t, g: g, script: make(map[int]snakeDirection)}
+}
+
+// return a new harness playing a game from a board, as text (see Board)
+func NewHarnessFromBoard(t *testing.T, text string) *Harness {
+	t.Helper()
+	b, err := ParseBoard(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewBoardGame(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Harness{t: t, g: g, script: make(map[int]snakeDirection)}
+}
+
+// return a new harness racing the ghost replaying best (on a board of best's size), from the end of the countdown
+func NewGhostHarness(t *testing.T, best *Replay) *Harness {
+	t.Helper()
+	g, err := NewHeadlessGame(best.Width, best.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.best = best
+	g.ghostMode = true
+	g.ChangeState(StateGameStart)
+	for g.state == StateGameStart {
+		g.UpdateGameStart()
+	}
+	return &Harness{t: t, g: g, script: make(map[int]snakeDirection)}
+}
+
+// add input to the script: steer in direction d at tick
+func (h *Harness) Steer(tick int, d snakeDirection) {
+	h.script[tick] = d
+}
+
+// play one tick, as Update does (without reading input or saving the replay), moving the ghost too
+// returns false once there is nothing left to play (the game is over)
+func (h *Harness) Step() bool {
+	g := h.g
+	switch g.state {
+	case StateInGame:
+		if d, ok := h.script[h.tick]; ok {
+			g.Steer(d)
+		}
+		g.StepInGame()
+		g.UpdateGhost()
+	case StateGameEnd:
+		g.UpdateEndGame()
+	default:
+		return false
+	}
+	h.tick++
+	return true
+}
+
+// play up to tick (or until the game is over)
+func (h *Harness) RunTo(tick int) {
+	for h.tick < tick && h.Step() {
+	}
+}
+
+// play until the snake has moved n more times (or the game is over), returning the tick reached
+func (h *Harness) Moves(n int) int {
+	want := len(h.g.replay.Moves) + n
+	for len(h.g.replay.Moves) < want && h.Step() {
+	}
+	return h.tick
+}
+
+// play the moves in the string (one of U, D, L or R per move), steering before each
+func (h *Harness) Play(moves string) {
+	for _, c := range moves {
+		h.g.Steer(snakeDirection(strings.IndexRune(replayMoveChars, c) + 1))
+		h.Moves(1)
+	}
+}
+
+// move the food to board position x, y
+func (h *Harness) PlaceFood(x, y int) {
+	h.g.food = &Food{x: x, y: y}
+}
+
+// fail unless the game is in state s
+func (h *Harness) AssertState(s gameState) {
+	h.t.Helper()
+	if h.g.state != s {
+		h.t.Fatalf("tick %d: state %d, want %d", h.tick, h.g.state, s)
+	}
+}
+
+// fail unless the snake's segments are at the given board positions, head first
+func (h *Harness) AssertSnake(want ...[2]int) {
+	h.t.Helper()
+	s := h.g.SnakeBody
+	got := make([][2]int, s.length)
+	for i := range got {
+		seg := s.Segment(i)
+		got[i] = [2]int{seg.x, seg.y}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		h.t.Fatalf("tick %d: snake at %v, want %v", h.tick, got, want)
+	}
+}
+
+// fail unless the game's board, as text (see Board), is want
+func (h *Harness) AssertBoard(want string) {
+	h.t.Helper()
+	if got := h.g.Board().String(); got != want {
+		h.t.Fatalf("tick %d: board\n%s\nwant\n%s", h.tick, got, want)
+	}
+}
+
+// fail unless the snake's segments are drawn with the given tiles, head first
+func (h *Harness) AssertTiles(want ...SegmentTile) {
+	h.t.Helper()
+	s := h.g.SnakeBody
+	got := make([]SegmentTile, s.length)
+	for i := range got {
+		got[i] = s.Tile(i)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		h.t.Fatalf("tick %d: tiles %v, want %v", h.tick, got, want)
+	}
+}
+
+// fail unless the board's occupancy matches the snake: its cells occupied, every other cell free
+func (h *Harness) AssertOccupancy() {
+	h.t.Helper()
+	g := h.g
+	want := make(map[[2]int]int)
+	for i := 0; i < g.SnakeBody.length; i++ {
+		seg := g.SnakeBody.Segment(i)
+		want[[2]int{seg.x, seg.y}]++
+	}
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			_, n := g.occupancy.At(x, y)
+			if n != want[[2]int{x, y}] {
+				h.t.Fatalf("tick %d: %d segments at %d,%d, want %d", h.tick, n, x, y, want[[2]int{x, y}])
+			}
+		}
+	}
+	if free := g.width*g.height - len(want); g.occupancy.FreeCells() != free {
+		h.t.Fatalf("tick %d: %d free cells, want %d", h.tick, g.occupancy.FreeCells(), free)
+	}
+}
+
+// render the current frame with the software renderer, and compare it to the golden frame testdata/golden/name.png
+// with -update, the golden frame is written instead
+func (h *Harness) AssertFrame(name string) {
+	h.t.Helper()
+	r, err := NewSoftRenderer(h.g)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	got := r.Frame()
+	path := filepath.Join("testdata", "golden", name+".png")
+	if *updateGolden {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = WritePNG(path, got)
+		}
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		h.t.Fatalf("no golden frame %s (run go test -update to write it)", path)
+	}
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		h.t.Fatalf("%s: %v", path, err)
+	}
+	if want.Bounds() != got.Bounds() {
+		h.t.Fatalf("frame %s is %v, golden frame is %v", name, got.Bounds(), want.Bounds())
+	}
+
+	// count the pixels that differ, reporting the first
+	diffs, first := 0, image.Point{}
+	b := got.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, a1 := got.At(x, y).RGBA()
+			r2, g2, b2, a2 := want.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				if diffs == 0 {
+					first = image.Pt(x, y)
+				}
+				diffs++
+			}
+		}
+	}
+	if diffs > 0 {
+		failed := filepath.Join(os.TempDir(), "snake-"+name+".png")
+		err = WritePNG(failed, got)
+		if err != nil {
+			h.t.Log(err)
+		}
+		h.t.Fatalf("frame %s differs from %s in %d pixels (first at %v), frame written to %s", name, path, diffs, first, failed)
+	}
+}
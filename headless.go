@@ -0,0 +1,33 @@
+//go:build headless
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// built with -tags headless, the game has no window, and doesn't need ebiten (or cgo, or a display) to build,
+// so it can render replays on machines without them; everything only needed to draw in a window is left out
+
+// no sprites, fonts, offscreen images or gamepads (see gameView in main_ebiten.go)
+type gameView struct{}
+
+// no atlas image (see atlasView in atlas_ebiten.go)
+type atlasView struct{}
+
+// no score bar or offscreen images to resize
+func (g *Game) ResizeView() {}
+
+// main function: without a window, the only command is render
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "render" {
+		fmt.Fprintln(os.Stderr, "usage: snake render [flags] replay.json (built without a window: -tags headless)")
+		os.Exit(2)
+	}
+	err := RenderCommand(os.Args[2:])
+	if err != nil {
+		log.Fatal(err)
+	}
+}
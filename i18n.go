@@ -0,0 +1,90 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Embedded message catalogues, one JSON file per language (named by language code)
+//
+//go:embed assets/locales/*.json
+var localeFiles embed.FS
+
+// language used when no other language is set, and for any missing messages
+const defaultLanguage = "en"
+
+// messages for one language, by message key
+type Catalogue map[string]string
+
+// load all embedded message catalogues, by language code
+func LoadCatalogues() (map[string]Catalogue, error) {
+	files, err := localeFiles.ReadDir("assets/locales")
+	if err != nil {
+		return nil, err
+	}
+	catalogues := make(map[string]Catalogue)
+	for _, f := range files {
+		b, err := localeFiles.ReadFile(path.Join("assets/locales", f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var c Catalogue
+		err = json.Unmarshal(b, &c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		catalogues[strings.TrimSuffix(f.Name(), ".json")] = c
+	}
+	if _, ok := catalogues[defaultLanguage]; !ok {
+		return nil, fmt.Errorf("no message catalogue for default language %q", defaultLanguage)
+	}
+	return catalogues, nil
+}
+
+// return the language codes of all catalogues, default language first
+func (g *Game) Languages() []string {
+	langs := make([]string, 0, len(g.catalogues))
+	for lang := range g.catalogues {
+		if lang != defaultLanguage {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return append([]string{defaultLanguage}, langs...)
+}
+
+// return the language code of the user's system language if there is a catalogue for it,
+// otherwise the default language
+func (g *Game) SystemLanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		// eg: "es_ES.UTF-8" -> "es"
+		lang := strings.ToLower(os.Getenv(env))
+		lang, _, _ = strings.Cut(lang, ".")
+		lang, _, _ = strings.Cut(lang, "_")
+		if _, ok := g.catalogues[lang]; ok {
+			return lang
+		}
+	}
+	return defaultLanguage
+}
+
+// return the message for key in the current language, formatted with args
+// falls back to the default language, then the key itself
+func (g *Game) T(key string, args ...any) string {
+	msg, ok := g.catalogues[g.settings.Language][key]
+	if !ok {
+		msg, ok = g.catalogues[defaultLanguage][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
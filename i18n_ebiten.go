@@ -0,0 +1,17 @@
+//go:build !headless
+
+package main
+
+// change the current language, reloading the UI font to suit
+func (g *Game) SetLanguage(lang string) error {
+	if _, ok := g.catalogues[lang]; !ok {
+		lang = g.SystemLanguage()
+	}
+	face, err := g.fonts.Face(lang)
+	if err != nil {
+		return err
+	}
+	g.settings.Language = lang
+	g.font = face
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+// Control schemes
+const (
+	// arrow keys / d-pad set the snake's direction
+	ControlsAbsolute = "absolute"
+
+	// two buttons turn the snake left or right, relative to the way it is facing
+	ControlsRelative = "relative"
+
+	// one button: tap to turn right, hold to turn left
+	ControlsSwitch = "switch"
+)
+
+// control schemes, in the order they are offered on the settings screen
+var controlSchemes = []struct {
+
+	// settings value for the control scheme
+	id string
+
+	// message key of the control scheme's name
+	name string
+}{
+	{ControlsAbsolute, "controls.absolute"},
+	{ControlsRelative, "controls.relative"},
+	{ControlsSwitch, "controls.switch"},
+}
+
+// ticks the switch must be held for to count as a hold rather than a tap
+const switchHoldTicks = 20
+
+// return the direction 90 degrees anticlockwise from d
+func TurnLeft(d snakeDirection) snakeDirection {
+	switch d {
+	case UP:
+		return LEFT
+	case LEFT:
+		return DOWN
+	case DOWN:
+		return RIGHT
+	default:
+		return UP
+	}
+}
+
+// return the direction 90 degrees clockwise from d
+func TurnRight(d snakeDirection) snakeDirection {
+	switch d {
+	case UP:
+		return RIGHT
+	case RIGHT:
+		return DOWN
+	case DOWN:
+		return LEFT
+	default:
+		return UP
+	}
+}
+
+// set the direction the snake will move next
+// the snake can only turn 90 degrees from the way its head is facing, so it can never reverse into itself
+func (g *Game) Steer(d snakeDirection) {
+	switch g.SnakeBody.Head().facing {
+	case LEFT, RIGHT:
+		if d == UP || d == DOWN {
+			g.SnakeDirection = d
+		}
+	case UP, DOWN:
+		if d == LEFT || d == RIGHT {
+			g.SnakeDirection = d
+		}
+	}
+}
+
+// key help for changing direction, according to the control scheme
+func (g *Game) ControlsHelp() [2]string {
+	switch g.settings.Controls {
+	case ControlsRelative:
+		return [2]string{g.T("key.leftright"), g.T("menu.turn")}
+	case ControlsSwitch:
+		return [2]string{g.T("key.space"), g.T("menu.switch")}
+	default:
+		return [2]string{g.T("key.arrows"), g.T("menu.change_direction")}
+	}
+}
@@ -0,0 +1,105 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// handle player input when in game, according to the control scheme
+func (g *Game) HandleInGameInput() {
+	switch g.settings.Controls {
+	case ControlsRelative:
+		g.HandleRelativeInput()
+	case ControlsSwitch:
+		g.HandleSwitchInput()
+	default:
+		g.HandleAbsoluteInput()
+	}
+}
+
+// absolute controls: arrow keys, gamepad d-pad or left stick set the direction
+func (g *Game) HandleAbsoluteInput() {
+	up := ebiten.IsKeyPressed(ebiten.KeyArrowUp)
+	down := ebiten.IsKeyPressed(ebiten.KeyArrowDown)
+	left := ebiten.IsKeyPressed(ebiten.KeyArrowLeft)
+	right := ebiten.IsKeyPressed(ebiten.KeyArrowRight)
+	for _, id := range g.Gamepads() {
+		x := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		y := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+		up = up || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftTop) || y < -0.5
+		down = down || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftBottom) || y > 0.5
+		left = left || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftLeft) || x < -0.5
+		right = right || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftRight) || x > 0.5
+	}
+	switch {
+	case up:
+		g.Steer(UP)
+	case down:
+		g.Steer(DOWN)
+	case left:
+		g.Steer(LEFT)
+	case right:
+		g.Steer(RIGHT)
+	}
+}
+
+// relative controls: left/right arrow keys, gamepad d-pad left/right or shoulder buttons turn the snake
+func (g *Game) HandleRelativeInput() {
+	left := inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft)
+	right := inpututil.IsKeyJustPressed(ebiten.KeyArrowRight)
+	for _, id := range g.Gamepads() {
+		left = left ||
+			inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonLeftLeft) ||
+			inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonFrontTopLeft)
+		right = right ||
+			inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonLeftRight) ||
+			inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonFrontTopRight)
+	}
+
+	// turn relative to the way the head is facing (not the pending direction),
+	// so pressing twice before the snake moves can't reverse it into itself
+	switch {
+	case left:
+		g.Steer(TurnLeft(g.SnakeBody.Head().facing))
+	case right:
+		g.Steer(TurnRight(g.SnakeBody.Head().facing))
+	}
+}
+
+// one switch controls: space, enter or gamepad A button
+// tap (release before switchHoldTicks) to turn right, hold to turn left
+func (g *Game) HandleSwitchInput() {
+	pressed := ebiten.IsKeyPressed(ebiten.KeySpace) || ebiten.IsKeyPressed(ebiten.KeyEnter)
+	for _, id := range g.Gamepads() {
+		pressed = pressed || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonRightBottom)
+	}
+
+	switch {
+	case pressed:
+		g.switchTicks++
+		// held long enough: turn left straight away, rather than waiting for release
+		if g.switchTicks == switchHoldTicks {
+			g.Steer(TurnLeft(g.SnakeBody.Head().facing))
+		}
+	case g.switchTicks > 0:
+		// released: a tap turns right (a hold has already turned left)
+		if g.switchTicks < switchHoldTicks {
+			g.Steer(TurnRight(g.SnakeBody.Head().facing))
+		}
+		g.switchTicks = 0
+	}
+}
+
+// return the IDs of connected gamepads with a standard layout
+func (g *Game) Gamepads() []ebiten.GamepadID {
+	g.gamepadIDs = ebiten.AppendGamepadIDs(g.gamepadIDs[:0])
+	ids := g.gamepadIDs[:0]
+	for _, id := range g.gamepadIDs {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
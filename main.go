@@ -1,65 +1,38 @@
 package main
 
 import (
-	"bytes"
 	_ "embed"
-	"errors"
-	"fmt"
-	"image"
-	"image/color"
 	_ "image/png"
-	"log"
 	"math"
 	"math/rand"
-
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
-// Embedded PNG files
+// Embedded sprite atlas
 var (
-	//go:embed assets/snakehead.png
-	pngSnakeHead []byte
-
-	//go:embed assets/snakebody.png
-	pngSnakeBody []byte
-
-	//go:embed assets/snaketail.png
-	pngSnakeTail []byte
-
-	//go:embed assets/snakebend.png
-	pngSnakeBend []byte
-
-	//go:embed assets/snaketongue.png
-	pngSnakeTongue []byte
+	//go:embed assets/atlas.png
+	pngAtlas []byte
 
-	//go:embed assets/snakeskeletonhead.png
-	pngSnakeSkeletonHead []byte
-
-	//go:embed assets/snakeskeletonbody.png
-	pngSnakeSkeletonBody []byte
-
-	//go:embed assets/snakeskeletontail.png
-	pngSnakeSkeletonTail []byte
-
-	//go:embed assets/snakeskeletonbend.png
-	pngSnakeSkeletonBend []byte
-
-	//go:embed assets/cupcake.png
-	pngCupcake []byte
+	//go:embed assets/atlas.json
+	jsonAtlas []byte
 )
 
 // Tile sizes are 16x16 (except for head with tongue out)
 const TILESIZE = 16
 
+// segments a new snake has room for before its ring buffer grows
+const snakeInitialCapacity = 64
+
+// random positions tried for new food before picking from the free cells
+const foodTries = 32
+
+// game ticks per second (ebiten's default), for working out times without asking ebiten
+const ticksPerSecond = 60
+
 // Custom game types
 type (
 	// the direction of the snake
 	snakeDirection uint8
 
-	// which tile to render for a given segment
-	snakeTile int
-
 	// the state of the game (which screen/mode)
 	gameState uint8
 )
@@ -81,108 +54,35 @@ const (
 
 	// game-over screen
 	StateGameOver
-)
-
-// Constants for snake direction
-const (
-	UP snakeDirection = iota + 1
-	DOWN
-	LEFT
-	RIGHT
-)
-
-// constants for snake tiles
-// format is 4 type bits + 4 rotation bits
-//
-// +----+----+----+----+----+----+----+----+
-// | T3 | T2 | T1 | T0 | R3 | R2 | R1 | R0 |
-// +----+----+----+----+----+----+----+----+
-//
-// Type bits:
-//
-//	0000 = head
-//	0001 = body
-//	0010 = bend
-//	0100 = tail
-//
-// Rotation bits:
-//
-//	0000 = no rotation
-//	0001 = left 90 deg
-//	0010 = right 90 deg
-//	0100 = 180 deg
-const (
-	// Type bitmask for Head tile
-	SnakeTypeHead = 0b00000000
-
-	// Type bitmask for Body tile
-	SnakeTypeBody = 0b00010000
-
-	// Type bitmask for Bend tile
-	SnakeTypeBend = 0b00100000
-
-	// Type bitmask for Tail tile
-	SnakeTypeTail = 0b01000000
-
-	// Rotation bitmask for no rptation
-	SnakeRotationNone = 0b00000000
-
-	// Rotation bitmask for CCW 90 deg
-	SnakeRotationCCW90 = 0b00000001
-
-	// Rotation bitmask for CW 90 deg
-	SnakeRotationCW90 = 0b00000010
-
-	// Rotation bitmask for 180 deg
-	SnakeRotation180 = 0b00000100
-
-	// Snake head, no rotation (facing up)
-	SnakeHeadUp = SnakeTypeHead + SnakeRotationNone
-
-	// Snake head, 180 deg rotation (facing down)
-	SnakeHeadDown = SnakeTypeHead + SnakeRotation180
-
-	// Snake head, -90 deg rotation (facing left)
-	SnakeHeadLeft = SnakeTypeHead + SnakeRotationCCW90
-
-	// Snake head, 90 deg rotation (racing right)
-	SnakeHeadRight = SnakeTypeHead + SnakeRotationCW90
-
-	// Snake body, no rotation (facing up)
-	SnakeBodyUp = SnakeTypeBody + SnakeRotationNone
-
-	// Snake body, 180 deg rotation (facing down)
-	SnakeBodyDown = SnakeTypeBody + SnakeRotation180
-
-	// Snake body, -90 deg rotation (facing left)
-	SnakeBodyLeft = SnakeTypeBody + SnakeRotationCCW90
-
-	// Snake body, 90 deg rotation (facing right)
-	SnakeBodyRight = SnakeTypeBody + SnakeRotationCW90
 
-	// Snake bend to connect left & down
-	SnakeBendLD = SnakeTypeBend + SnakeRotationNone
+	// perfect game: the snake has filled the board
+	StateGameWon
 
-	// Snake bend to connect right & up
-	SnakeBendRU = SnakeTypeBend + SnakeRotation180
+	// settings screen
+	StateSettings
 
-	// Snake bend to connect right & down
-	SnakeBendRD = SnakeTypeBend + SnakeRotationCCW90
+	// list of saved replays
+	StateReplayList
 
-	// Snake bend to connect left & up
-	SnakeBendLU = SnakeTypeBend + SnakeRotationCW90
+	// watching a replay
+	StateReplayView
 
-	// Snake tail, no rotation (facing up)
-	SnakeTailUp = SnakeTypeTail + SnakeRotationNone
+	// achievements screen
+	StateAchievements
 
-	// Snake tail, 180 deg rotation (facing down)
-	SnakeTailDown = SnakeTypeTail + SnakeRotation180
+	// game paused, with the pause menu over it
+	StatePaused
 
-	// Snake tail, -90 deg rotation (facing left)
-	SnakeTailLeft = SnakeTypeTail + SnakeRotationCCW90
+	// asking whether to quit, over the game being played
+	StateQuitConfirm
+)
 
-	// Snake tail, 90 deg rotation (racing right)
-	SnakeTailRight = SnakeTypeTail + SnakeRotationCW90
+// Constants for snake direction
+const (
+	UP snakeDirection = iota + 1
+	DOWN
+	LEFT
+	RIGHT
 )
 
 // Struct representing snake food
@@ -199,25 +99,30 @@ type SnakeBodySegment struct {
 	// Direction segment is pointing
 	facing snakeDirection
 
-	// Tile representing segment
-	tile snakeTile
-
-	// Next segment (or nil) - linked list
-	next *SnakeBodySegment
-
 	// Is the segment a skeleton?
 	skeleton bool
+
+	// Is the segment mirrored (having crossed edges of the board that flip it over an odd number of times)?
+	mirrored bool
 }
 
 // struct representing the entire body of the snake
 type SnakeBody struct {
 
-	// first segment
-	Head *SnakeBodySegment
+	// segments, in a ring buffer: segment i (0 is the head) is segs[(head+i)%len(segs)]
+	segs []SnakeBodySegment
+	head int
 
 	// does the snake grow next update?
 	grow bool
 
+	// how the snake last moved, for smooth movement: is it moving (it has moved since it spawned,
+	// and hasn't died or filled the board), and did its last move grow it (leaving its tail where it was)?
+	moving, grew bool
+
+	// board occupancy kept up to date as the snake moves (nil if the snake isn't on the board, like the title's)
+	board *Occupancy
+
 	// length of snake
 	length int
 }
@@ -231,34 +136,30 @@ type Game struct {
 	// size of game board (in snake segments of size TILESIZExTILESIZE)
 	width, height int
 
-	// snake tiles
-
-	ImgSnakeHead          *ebiten.Image
-	ImgSnakeBody          *ebiten.Image
-	ImgSnakeTail          *ebiten.Image
-	ImgSnakeBend          *ebiten.Image
-	ImgSnakeTongue        *ebiten.Image
-	ImgSnakeHeadTongueOut *ebiten.Image
+	// usual size of game board (daily challenges & their replays may differ)
+	defaultWidth, defaultHeight int
 
-	// snake skeleton
-
-	ImgSnakeSkeletonHead *ebiten.Image
-	ImgSnakeSkeletonBody *ebiten.Image
-	ImgSnakeSkeletonTail *ebiten.Image
-	ImgSnakeSkeletonBend *ebiten.Image
+	// rules of the current game
+	rules Rules
 
 	// snake object
 	SnakeBody *SnakeBody
 
+	// which board cells the snake occupies
+	occupancy *Occupancy
+
 	// direction snake is facing
 	SnakeDirection snakeDirection
 
-	// food tile
-	ImgFood *ebiten.Image
-
 	// food object
 	food *Food
 
+	// seed for the current game (food placement)
+	seed uint64
+
+	// replay of the current game, recorded as it is played
+	replay *Replay
+
 	// movement speed stuff
 
 	ticks            int
@@ -266,11 +167,11 @@ type Game struct {
 
 	// score stuff
 
-	score    int
-	scoreBar *ebiten.Image
+	score   int
+	scoring Scoring
 
-	// title screen text
-	textSnake *ebiten.Image
+	// message catalogues, by language code
+	catalogues map[string]Catalogue
 
 	// start game countdown
 
@@ -287,220 +188,79 @@ type Game struct {
 	tongueShow     bool
 	tongueTicks    int
 	tongueTicksMin int
-}
 
-// draws the text SNAKE out of snakes
-func (g *Game) InitTitleScreen(imgOut *ebiten.Image) {
-	S := g.SpawnSnake(3, 1)
-	g.SnakeMove(S, LEFT, false, false)
-	g.SnakeMove(S, LEFT, false, false)
-	g.SnakeAdvance(S, DOWN)
-	g.SnakeAdvance(S, DOWN)
-	g.SnakeAdvance(S, RIGHT)
-	g.SnakeAdvance(S, RIGHT)
-	g.SnakeAdvance(S, DOWN)
-	g.SnakeAdvance(S, DOWN)
-	g.SnakeAdvance(S, DOWN)
-	g.SnakeAdvance(S, DOWN)
-	g.SnakeAdvance(S, LEFT)
-	g.SnakeAdvance(S, LEFT)
-	g.SnakeAdvance(S, LEFT)
-	g.SnakeAdvance(S, UP)
-	g.SnakeAdvance(S, RIGHT)
-	g.SnakeAdvance(S, RIGHT)
-	g.SnakeAdvance(S, UP)
-	g.SnakeAdvance(S, UP)
-	g.SnakeAdvance(S, LEFT)
-	g.SnakeAdvance(S, LEFT)
-	g.SnakeAdvance(S, UP)
-	g.SnakeAdvance(S, UP)
-	g.SnakeAdvance(S, UP)
-	g.SnakeAdvance(S, UP)
-	g.SnakeAdvance(S, RIGHT)
-	g.SnakeAdvance(S, RIGHT)
-	g.SnakeAdvance(S, RIGHT)
-
-	N := g.SpawnSnake(8, 7)
-	g.SnakeMove(N, UP, false, false)
-	g.SnakeMove(N, UP, false, false)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, LEFT)
-	g.SnakeAdvance(N, LEFT)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, LEFT)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, UP)
-	g.SnakeAdvance(N, RIGHT)
-	g.SnakeAdvance(N, RIGHT)
-	g.SnakeAdvance(N, RIGHT)
-	g.SnakeAdvance(N, RIGHT)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-	g.SnakeAdvance(N, DOWN)
-
-	A := g.SpawnSnake(14, 2)
-	g.SnakeMove(A, UP, false, false)
-	g.SnakeMove(A, LEFT, false, false)
-	g.SnakeAdvance(A, LEFT)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, LEFT)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, RIGHT)
-	g.SnakeAdvance(A, RIGHT)
-	g.SnakeAdvance(A, RIGHT)
-	g.SnakeAdvance(A, RIGHT)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, DOWN)
-	g.SnakeAdvance(A, LEFT)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, UP)
-	g.SnakeAdvance(A, LEFT)
-
-	K := g.SpawnSnake(18, 6)
-	g.SnakeMove(K, DOWN, false, false)
-	g.SnakeMove(K, LEFT, false, false)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, RIGHT)
-	g.SnakeAdvance(K, DOWN)
-	g.SnakeAdvance(K, DOWN)
-	g.SnakeAdvance(K, DOWN)
-	g.SnakeAdvance(K, DOWN)
-	g.SnakeAdvance(K, DOWN)
-	g.SnakeAdvance(K, RIGHT)
-	g.SnakeAdvance(K, RIGHT)
-	g.SnakeAdvance(K, DOWN)
-	g.SnakeAdvance(K, DOWN)
-	g.SnakeAdvance(K, RIGHT)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, LEFT)
-	g.SnakeAdvance(K, LEFT)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, RIGHT)
-	g.SnakeAdvance(K, RIGHT)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, UP)
-	g.SnakeAdvance(K, LEFT)
-	g.SnakeAdvance(K, DOWN)
-
-	E := g.SpawnSnake(26, 3)
-	g.SnakeMove(E, LEFT, false, false)
-	g.SnakeMove(E, LEFT, false, false)
-	g.SnakeAdvance(E, UP)
-	g.SnakeAdvance(E, UP)
-	g.SnakeAdvance(E, RIGHT)
-	g.SnakeAdvance(E, RIGHT)
-	g.SnakeAdvance(E, UP)
-	g.SnakeAdvance(E, LEFT)
-	g.SnakeAdvance(E, LEFT)
-	g.SnakeAdvance(E, LEFT)
-	g.SnakeAdvance(E, DOWN)
-	g.SnakeAdvance(E, DOWN)
-	g.SnakeAdvance(E, DOWN)
-	g.SnakeAdvance(E, DOWN)
-	g.SnakeAdvance(E, DOWN)
-	g.SnakeAdvance(E, DOWN)
-	g.SnakeAdvance(E, DOWN)
-	g.SnakeAdvance(E, RIGHT)
-	g.SnakeAdvance(E, RIGHT)
-	g.SnakeAdvance(E, RIGHT)
-	g.SnakeAdvance(E, UP)
-	g.SnakeAdvance(E, LEFT)
-	g.SnakeAdvance(E, LEFT)
-	g.SnakeAdvance(E, UP)
-	g.SnakeAdvance(E, UP)
-	g.SnakeAdvance(E, RIGHT)
-	g.SnakeAdvance(E, RIGHT)
-
-	g.DrawSnake(S, imgOut, 0, false)
-	g.DrawSnake(N, imgOut, 0, false)
-	g.DrawSnake(A, imgOut, 0, false)
-	g.DrawSnake(K, imgOut, 0, false)
-	g.DrawSnake(E, imgOut, 0, false)
-}
+	// user settings
+	settings       Settings
+	settingsCursor int
 
-// works out the next position of the snake
-func (g *Game) SnakeGetNextPos(SnakeBody *SnakeBody, d snakeDirection) (x, y int) {
+	// effects
 
-	switch d {
-	case UP:
-		y = int(SnakeBody.Head.y) - 1
-		x = int(SnakeBody.Head.x)
-	case DOWN:
-		y = int(SnakeBody.Head.y) + 1
-		x = int(SnakeBody.Head.x)
-	case LEFT:
-		y = int(SnakeBody.Head.y)
-		x = int(SnakeBody.Head.x) - 1
-	case RIGHT:
-		y = int(SnakeBody.Head.y)
-		x = int(SnakeBody.Head.x) + 1
-	}
+	particles     []Particle
+	scorePopups   []ScorePopup
+	shakeTicks    int
+	shakeTicksMax int
 
-	// bounds checking - wrap around the screen if needed
-	if x < 0 {
-		x = g.width - 1
-	}
-	if x > g.width-1 {
-		x = 0
-	}
-	if y < 0 {
-		y = g.height - 1
-	}
-	if y > g.height-1 {
-		y = 0
-	}
+	// the camera's view of the board
+	camera Camera
+
+	// ticks since window size & position were last checked
+	windowTicks int
+
+	// size of the screen (window or fullscreen) in device pixels, as last laid out
+	screenWidth, screenHeight int
+
+	// screenshot & GIF capture
+	capture *Recorder
+
+	// replay list & viewer
+
+	replays      []ReplayFile
+	replayCursor int
+	viewer       *ReplayViewer
+
+	// ghost race: racing a ghost replaying the best replay
 
+	ghostMode bool
+	ghost     *ReplayPlayer
+	best      *Replay
+
+	// achievements: progress, stats for the current game, and toasts for achievements just unlocked
+
+	achievements *AchievementRecord
+	stats        GameStats
+	toasts       []string
+	toastTicks   int
+
+	// daily challenge being played (nil if not), and results of past daily challenges
+
+	daily        *Daily
+	dailyResults DailyResults
+
+	// game saved to be resumed from the main menu (nil if none)
+	saved *SavedGame
+
+	// state to go back to if the player decides not to quit
+	quitFrom gameState
+
+	// input stuff
+
+	switchTicks int
+
+	// sprites, fonts, offscreen images & gamepads, for drawing the game & reading input in a window
+	// (see gameView: games without a window, like NewHeadlessGame's, have none)
+	gameView
+}
+
+// works out the next position of the snake
+func (g *Game) SnakeGetNextPos(SnakeBody *SnakeBody, d snakeDirection) (x, y int) {
+	x, y, _, _ = g.NextPosition(SnakeBody.Head().x, SnakeBody.Head().y, d)
 	return x, y
 }
 
 // check to see if the head of the snake is on the food tile
 func (g *Game) SnakeCheckFood(SnakeBody *SnakeBody) bool {
 	// check if snake just ate food
-	seg := SnakeBody.Head
+	seg := SnakeBody.Head()
 	if seg.x == g.food.x && seg.y == g.food.y {
 		g.score++
 		SnakeBody.grow = true
@@ -509,62 +269,28 @@ func (g *Game) SnakeCheckFood(SnakeBody *SnakeBody) bool {
 	return false
 }
 
-// check to see if the head of the snake will eat the snake body
+// check to see if the head of the snake will eat a snake (itself or another), or hit a wall
+// checked before the snake moves, so a tail about to move out of the way is handled by the rules
 func (g *Game) SnakeCheckDeath(SnakeBody *SnakeBody, d snakeDirection) bool {
-	// check if snake has eaten itself
-	x, y := g.SnakeGetNextPos(SnakeBody, d)
-	seg := g.SnakeBody.Head.next
-	for {
-		if seg.x == x && seg.y == y {
-			return true
-		}
-		if seg.next == nil {
-			break
-		}
-		seg = seg.next
-	}
-	return false
-}
-
-// delete tail segment
-func (g *Game) SnakeRemoveTail(SnakeBody *SnakeBody) {
-	// set second last segment's 'next' to nil
-	prevSeg := SnakeBody.Head
-	seg := SnakeBody.Head
-	for i := 1; i < SnakeBody.length-1; i++ {
-		prevSeg = seg
-		seg = seg.next
+	// check if snake has hit a wall
+	x, y, _, ok := g.NextPosition(SnakeBody.Head().x, SnakeBody.Head().y, d)
+	if !ok {
+		return true
 	}
-	seg.next = nil
-	SnakeBody.length--
 
-	// set tail direction
+	// check if snake has run into any snake on the board (itself included)
+	o, n := g.occupancy.At(x, y)
 	switch {
-	case prevSeg.x < seg.x:
-		if math.Abs(float64(prevSeg.x-seg.x)) == 1 {
-			seg.tile = SnakeTailLeft
-		} else {
-			seg.tile = SnakeTailRight
-		}
-	case prevSeg.x > seg.x:
-		if math.Abs(float64(prevSeg.x-seg.x)) == 1 {
-			seg.tile = SnakeTailRight
-		} else {
-			seg.tile = SnakeTailLeft
-		}
-	case prevSeg.y < seg.y:
-		if math.Abs(float64(prevSeg.y-seg.y)) == 1 {
-			seg.tile = SnakeTailUp
-		} else {
-			seg.tile = SnakeTailDown
-		}
-	case prevSeg.y > seg.y:
-		if math.Abs(float64(prevSeg.y-seg.y)) == 1 {
-			seg.tile = SnakeTailDown
-		} else {
-			seg.tile = SnakeTailUp
-		}
+	case n == 0:
+		return false
+	case n > 1:
+		return true
+
+	// following a tail: it moves out of the way, unless the snake is growing or tails block
+	case o.Tail().x == x && o.Tail().y == y:
+		return o.grow || g.rules.TailBlocks
 	}
+	return true
 }
 
 // move snake forward one segment in direction d
@@ -574,12 +300,15 @@ func (g *Game) SnakeMove(SnakeBody *SnakeBody, d snakeDirection, checkDeath, che
 	// remove old tail segment if not growing
 	if checkDeath {
 		if g.SnakeCheckDeath(SnakeBody, d) {
+			SnakeBody.moving = false
 			g.ChangeState(StateGameEnd)
 			return
 		}
 	}
+	SnakeBody.moving = true
+	SnakeBody.grew = SnakeBody.grow
 	if !SnakeBody.grow {
-		g.SnakeRemoveTail(SnakeBody)
+		SnakeBody.PopTail()
 		g.SnakeAdvance(SnakeBody, d)
 	} else {
 		g.SnakeAdvance(SnakeBody, d)
@@ -588,291 +317,207 @@ func (g *Game) SnakeMove(SnakeBody *SnakeBody, d snakeDirection, checkDeath, che
 	if checkFood {
 		if g.SnakeCheckFood(SnakeBody) {
 			SnakeBody.grow = true
-			g.SpawnFood()
+			g.AchievementEvent(EventCupcake)
+			g.EmitCrumbs(g.food.x, g.food.y)
+			g.AddScorePopup(g.food.x, g.food.y, g.ScoreCupcake())
+			if !g.SpawnFood() {
+				SnakeBody.moving = false
+				g.ChangeState(StateGameWon)
+			}
 		}
 	}
 }
 
 // advance the snake in direction d by adding a new head piece
+// (mirrored, if crossing an edge of the board that flips it over)
 func (g *Game) SnakeAdvance(SnakeBody *SnakeBody, d snakeDirection) {
-
-	var headTile snakeTile
-
-	// determine:
-	//  - tile for previous segment
-	//  - tile for head segment
-	switch d {
-	case UP:
-		headTile = SnakeHeadUp
-		switch SnakeBody.Head.facing {
-		case LEFT:
-			SnakeBody.Head.tile = SnakeBendRU
-		case RIGHT:
-			SnakeBody.Head.tile = SnakeBendLU
-		default:
-			SnakeBody.Head.tile = SnakeBodyUp
-		}
-	case DOWN:
-		headTile = SnakeHeadDown
-		switch SnakeBody.Head.facing {
-		case LEFT:
-			SnakeBody.Head.tile = SnakeBendRD
-		case RIGHT:
-			SnakeBody.Head.tile = SnakeBendLD
-		default:
-			SnakeBody.Head.tile = SnakeBodyDown
-		}
-	case LEFT:
-		headTile = SnakeHeadLeft
-		switch SnakeBody.Head.facing {
-		case UP:
-			SnakeBody.Head.tile = SnakeBendLD
-		case DOWN:
-			SnakeBody.Head.tile = SnakeBendLU
-		default:
-			SnakeBody.Head.tile = SnakeBodyLeft
-		}
-	case RIGHT:
-		headTile = SnakeHeadRight
-		switch SnakeBody.Head.facing {
-		case UP:
-			SnakeBody.Head.tile = SnakeBendRD
-		case DOWN:
-			SnakeBody.Head.tile = SnakeBendRU
-		default:
-			SnakeBody.Head.tile = SnakeBodyRight
-		}
-	}
-
-	// update x/y coords based on direction of snake travel
-	x, y := g.SnakeGetNextPos(SnakeBody, d)
-	// create new head segment
-	seg := SnakeBodySegment{
-		x:      x,
-		y:      y,
-		facing: d,
-		next:   SnakeBody.Head,
-		tile:   headTile,
-	}
-
-	// set head as new segment
-	SnakeBody.Head = &seg
-	SnakeBody.length++
+	head := SnakeBody.Head()
+	x, y, flipped, _ := g.NextPosition(head.x, head.y, d)
+	SnakeBody.PushHead(SnakeBodySegment{
+		x:        x,
+		y:        y,
+		facing:   d,
+		mirrored: head.mirrored != flipped,
+	})
 }
 
 // Create a new snake
 func (g *Game) SpawnSnake(startXPos, startYPos int) *SnakeBody {
 
 	// create initial segments (3 segments, facing up)
-	segTail := SnakeBodySegment{
+	segs := make([]SnakeBodySegment, snakeInitialCapacity)
+	segs[0] = SnakeBodySegment{
 		x:      startXPos,
-		y:      startYPos + 2,
+		y:      startYPos,
 		facing: UP,
-		next:   nil,
-		tile:   SnakeTailUp,
 	}
-	segMiddle := SnakeBodySegment{
+	segs[1] = SnakeBodySegment{
 		x:      startXPos,
 		y:      startYPos + 1,
 		facing: UP,
-		next:   &segTail,
-		tile:   SnakeBodyUp,
 	}
-	segHead := SnakeBodySegment{
+	segs[2] = SnakeBodySegment{
 		x:      startXPos,
-		y:      startYPos,
+		y:      startYPos + 2,
 		facing: UP,
-		next:   &segMiddle,
-		tile:   SnakeHeadUp,
 	}
 
 	// create body
 	sb := SnakeBody{
-		Head:   &segHead,
+		segs:   segs,
 		length: 3,
 	}
 
 	return &sb
 }
 
-// spawn the food tile at a random position not occupied by the snake
-func (g *Game) SpawnFood() {
-	var x int
-	var y int
-	var taken bool
-	for {
-		// generate a random position
-		x = rand.Intn(g.width - 1)
-		y = rand.Intn(g.height - 1)
-
-		// check to see if position is taken
-		taken = false
-		seg := g.SnakeBody.Head
-		for {
-			if x == seg.x && y == seg.y {
-				taken = true
-				break
-			}
-			if seg.next == nil {
-				break
-			}
-			seg = seg.next
+// return the head segment
+func (s *SnakeBody) Head() *SnakeBodySegment {
+	return &s.segs[s.head]
+}
+
+// return the tail segment
+func (s *SnakeBody) Tail() *SnakeBodySegment {
+	return s.Segment(s.length - 1)
+}
+
+// return segment i, counting from the head (0)
+// segments stay put only until the snake next grows
+func (s *SnakeBody) Segment(i int) *SnakeBodySegment {
+	return &s.segs[(s.head+i)%len(s.segs)]
+}
+
+// add a new head segment, doubling the ring buffer if it is full
+func (s *SnakeBody) PushHead(seg SnakeBodySegment) {
+	if s.length == len(s.segs) {
+		segs := make([]SnakeBodySegment, 2*len(s.segs))
+		for i := 0; i < s.length; i++ {
+			segs[i+1] = *s.Segment(i)
 		}
-		if !taken {
+		s.segs = segs
+		s.head = 1
+	}
+	s.head = (s.head + len(s.segs) - 1) % len(s.segs)
+	s.segs[s.head] = seg
+	s.length++
+	if s.board != nil {
+		s.board.Add(s, seg.x, seg.y)
+	}
+}
+
+// remove the tail segment
+func (s *SnakeBody) PopTail() {
+	seg := s.Tail()
+	if s.board != nil {
+		s.board.Remove(seg.x, seg.y)
+	}
+	s.length--
+}
+
+// return a copy of the snake, sharing no segments with the original
+// the copy isn't on the board
+func (s *SnakeBody) Clone() *SnakeBody {
+	sb := *s
+	sb.segs = make([]SnakeBodySegment, len(s.segs))
+	copy(sb.segs, s.segs)
+	sb.board = nil
+	return &sb
+}
+
+// spawn the food tile at a random position not occupied by the snake, chosen uniformly from all free cells
+// each cupcake's position comes from its own random sequence (numbered by the score), so games with
+// the same seed get the same cupcakes in the same places (where free), however they are played
+// returns false if there are no free cells left (the snake fills the board)
+func (g *Game) SpawnFood() bool {
+	free := g.occupancy.FreeCells()
+	if free == 0 {
+		return false
+	}
+	rng := NewRNG(g.seed, uint64(g.score))
+
+	// try random positions anywhere on the board,
+	// then (when the board is nearly full) pick one of the free cells instead
+	x, y := rng.Intn(g.width), rng.Intn(g.height)
+	for i := 1; g.occupancy.Occupied(x, y); i++ {
+		if i >= foodTries {
+			x, y = g.occupancy.FreeCell(rng.Intn(free))
 			break
 		}
+		x, y = rng.Intn(g.width), rng.Intn(g.height)
 	}
 	f := Food{
 		x: x,
 		y: y,
 	}
 	g.food = &f
+	return true
 }
 
-// draw the food tile, offsetting by yOffset (for score bar)
-func (g *Game) DrawFood(imgOut *ebiten.Image, yOffset int, dimmed bool) {
-	op := ebiten.DrawImageOptions{}
-	xpos := g.food.x * g.ImgFood.Bounds().Dx()
-	ypos := g.food.y * g.ImgFood.Bounds().Dy()
-
-	// translate
-	op.GeoM.Translate(float64(xpos), float64(ypos+yOffset))
-
-	// if game over, fade slightly
-	if dimmed {
-		op.ColorScale.ScaleAlpha(0.5)
+// how far the snake is from the cells it has just left to the cells it is in (0 to 1), for smooth movement
+// returns 1 (in its cells) if smooth movement is disabled, or the snake isn't moving
+// (this is 0 on the tick the snake moves, so the snake slides on from where it was drawn the tick before)
+func (g *Game) MovementProgress() float64 {
+	if !g.settings.SmoothMovement || g.ticksPerMovement <= 0 || !g.SnakeBody.moving {
+		return 1
 	}
-	imgOut.DrawImage(g.ImgFood, &op)
+	return math.Min(math.Max(float64(g.ticks)/float64(g.ticksPerMovement), 0), 1)
 }
 
-// draw the snake, offsetting by yOffset (for score bar)
-func (g *Game) DrawSnake(SnakeBody *SnakeBody, imgOut *ebiten.Image, yOffset int, dimmed bool) {
-	var img *ebiten.Image
-	var rotation float64
-	op := ebiten.DrawImageOptions{}
-	seg := SnakeBody.Head
-	for {
-		op.GeoM.Reset()
-		op.ColorScale.Reset()
-
-		// tile type (mask tile type bits with bitwise AND)
-		switch seg.tile & 0b11110000 {
-		case SnakeTypeHead:
-			if seg.skeleton {
-				img = g.ImgSnakeSkeletonHead
-			} else {
-				if g.tongueShow {
-					img = g.ImgSnakeHeadTongueOut
-					// as tongue out tile is 32 px high, we need to move up by 16px so the rest of the transforms/rotations work as expected
-					op.GeoM.Translate(0, -TILESIZE)
-				} else {
-					img = g.ImgSnakeHead
-				}
-			}
-
-		case SnakeTypeBody:
-			if seg.skeleton {
-				img = g.ImgSnakeSkeletonBody
-			} else {
-				img = g.ImgSnakeBody
-			}
-
-		case SnakeTypeBend:
-			if seg.skeleton {
-				img = g.ImgSnakeSkeletonBend
-			} else {
-				img = g.ImgSnakeBend
-			}
-
-		case SnakeTypeTail:
-			if seg.skeleton {
-				img = g.ImgSnakeSkeletonTail
-			} else {
-				img = g.ImgSnakeTail
-			}
-		default:
-			panic(seg.tile)
-		}
-
-		// rotation (mask rotation bits with bitwise AND)
-		switch seg.tile & 0b00001111 {
-		case SnakeRotationNone:
-			rotation = 0
-		case SnakeRotationCCW90:
-			rotation = -90 * math.Pi / 180
-		case SnakeRotationCW90:
-			rotation = 90 * math.Pi / 180
-		case SnakeRotation180:
-			rotation = math.Pi
-		default:
-			panic(seg.tile)
-		}
-
-		// get pixel position for segment
-		xpos := seg.x * TILESIZE
-		ypos := seg.y * TILESIZE
-
-		// rotate
-		if rotation != 0 {
-			RotateTile(img, &op, rotation)
-		}
-
-		// translate
-		op.GeoM.Translate(float64(xpos), float64(ypos+yOffset))
-
-		// if game over, fade slightly
-		if dimmed {
-			op.ColorScale.ScaleAlpha(0.5)
-		}
-
-		// draw tile
-		imgOut.DrawImage(img, &op)
-
-		// if we've reached the last segment, bail out
-		if seg.next == nil {
-			break
+// update function for when counting down to the start of a game
+func (g *Game) UpdateGameStart() error {
+	// count down to game start
+	g.countDownTicks++
+	if g.countDownTicks >= 60 {
+		g.countDownNum--
+		g.countDownTicks = 0
+	}
+	// if countdown finished, progress to in-game, with the ghost if racing it
+	if g.countDownNum < 0 {
+		g.ChangeState(StateInGame)
+		if g.ghostMode && g.best != nil {
+			g.StartGhost(g.best)
 		}
-
-		// get next segment for next iteration
-		seg = seg.next
 	}
+	return nil
 }
 
-// update function for when in game
-func (g *Game) UpdateInGame() error {
+// advance the game by one tick, moving the snake in g.SnakeDirection when it is due to move
+// each move is recorded to the replay
+func (g *Game) StepInGame() {
 
-	// handle input
-	switch {
-	case ebiten.IsKeyPressed(ebiten.KeyArrowUp):
-		if g.SnakeBody.Head.facing == LEFT || g.SnakeBody.Head.facing == RIGHT {
-			g.SnakeDirection = UP
-		}
-	case ebiten.IsKeyPressed(ebiten.KeyArrowDown):
-		if g.SnakeBody.Head.facing == LEFT || g.SnakeBody.Head.facing == RIGHT {
-			g.SnakeDirection = DOWN
-		}
-	case ebiten.IsKeyPressed(ebiten.KeyArrowLeft):
-		if g.SnakeBody.Head.facing == UP || g.SnakeBody.Head.facing == DOWN {
-			g.SnakeDirection = LEFT
-		}
-	case ebiten.IsKeyPressed(ebiten.KeyArrowRight):
-		if g.SnakeBody.Head.facing == UP || g.SnakeBody.Head.facing == DOWN {
-			g.SnakeDirection = RIGHT
-		}
-	}
+	g.AchievementEvent(EventTick)
 
 	// movement speed
 	g.ticks++
 	if g.ticks >= g.ticksPerMovement {
 		g.ticks = 0
+		if g.SnakeDirection != g.SnakeBody.Head().facing {
+			g.EmitDust(g.SnakeBody.Head().x, g.SnakeBody.Head().y)
+		}
+		g.replay.Record(g.SnakeDirection)
+		x, y := g.SnakeBody.Head().x, g.SnakeBody.Head().y
+		fx, fy := g.food.x, g.food.y
+		turned := g.SnakeDirection != g.SnakeBody.Head().facing
 		g.SnakeMove(g.SnakeBody, g.SnakeDirection, true, true)
-		g.ticksPerMovement = 40 - int(math.Min(float64(g.score), 33))
-	}
 
-	// random snake tongue
-	g.RandomSnakeTongue()
+		// did the snake cross the edge of the board?
+		if g.state == StateInGame {
+			dx, dy := DirectionDelta(g.SnakeDirection)
+			wrapped := g.SnakeBody.Head().x != x+dx || g.SnakeBody.Head().y != y+dy
+			if wrapped {
+				g.AchievementEvent(EventWrap)
+			}
+			g.ScoreMove(x, y, fx, fy, turned, wrapped)
+		}
 
-	return nil
+		speed := g.Speed()
+		g.ticksPerMovement = max(speed.base-g.score, speed.fastest)
+	}
+}
+
+// is the snake due to move on the next tick?
+func (g *Game) MoveDue() bool {
+	return g.ticks+1 >= g.ticksPerMovement
 }
 
 // random snake tongue
@@ -890,18 +535,6 @@ func (g *Game) RandomSnakeTongue() {
 	}
 }
 
-// update function for when in game over state
-func (g *Game) UpdateGameOver() error {
-	// handle input
-	switch {
-	case ebiten.IsKeyPressed(ebiten.KeySpace):
-		g.ChangeState(StateGameStart)
-	case ebiten.IsKeyPressed(ebiten.KeyEscape):
-		g.ChangeState(StateMainMenu)
-	}
-	return nil
-}
-
 // update function for when in end game state
 func (g *Game) UpdateEndGame() error {
 	finished := true
@@ -910,17 +543,14 @@ func (g *Game) UpdateEndGame() error {
 	g.skeleTicks++
 	if g.skeleTicks >= g.skeleTicksPerSegment {
 		g.skeleTicks = 0
-		seg := g.SnakeBody.Head
-		for {
+		for i := 0; i < g.SnakeBody.length; i++ {
+			seg := g.SnakeBody.Segment(i)
 			if !seg.skeleton {
 				seg.skeleton = true
+				g.EmitBones(seg.x, seg.y)
 				finished = false
 				break
 			}
-			if seg.next == nil {
-				break
-			}
-			seg = seg.next
 		}
 		// if all segments are skeleton advance to game over state
 		if finished {
@@ -930,330 +560,106 @@ func (g *Game) UpdateEndGame() error {
 	return nil
 }
 
-// update main menu, move random background snake
-func (g *Game) UpdateMainMenu() error {
-
-	// press space to start
-	if ebiten.IsKeyPressed(ebiten.KeySpace) {
-		g.ChangeState(StateGameStart)
+// change the size of the game board, resizing the score bar & offscreen images to suit
+func (g *Game) SetBoardSize(width, height int) {
+	if width == g.width && height == g.height {
+		return
 	}
-
-	// movement speed & random direction
-	g.ticks++
-	if g.ticks >= g.ticksPerMovement {
-		g.ticks = 0
-		g.SnakeMove(g.SnakeBody, RandomSnakeDirection(g.SnakeBody.Head.facing), false, false)
+	g.width = width
+	g.height = height
+	g.occupancy = NewOccupancy(width, height)
+	if g.SnakeBody != nil {
+		g.occupancy.AddSnake(g.SnakeBody)
 	}
-
-	// random snake tongue
-	g.RandomSnakeTongue()
-
-	return nil
-}
-
-// update function, ebiten calls this every tick (60 times per second)
-func (g *Game) Update() error {
-	var err error
-
-	// Press Q to quit regardless of state
-	if ebiten.IsKeyPressed(ebiten.KeyQ) {
-		return errors.New("Q pressed")
-	}
-
-	switch g.state {
-
-	// main menu
-	case StateMainMenu:
-		err = g.UpdateMainMenu()
-
-	// game start (countdown)
-	case StateGameStart:
-		// count down to game start
-		g.countDownTicks++
-		if g.countDownTicks >= 60 {
-			g.countDownNum--
-			g.countDownTicks = 0
-		}
-		// if countdown finished, progress to in-game
-		if g.countDownNum < 0 {
-			g.ChangeState(StateInGame)
-		}
-
-	// in-game (user controls snake)
-	case StateInGame:
-		err = g.UpdateInGame()
-
-	// end game (turn to skeleton)
-	case StateGameEnd:
-		err = g.UpdateEndGame()
-
-	// game over (game over screen)
-	case StateGameOver:
-		err = g.UpdateGameOver()
-	}
-
-	return err
-}
-
-// draw the score bar at the top of the screen
-func (g *Game) DrawScoreBar(imgOut *ebiten.Image) {
-	imgOut.DrawImage(g.scoreBar, &ebiten.DrawImageOptions{})
-	txt := fmt.Sprintf("Calories: %d", g.score*200)
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2, 0)
-}
-
-// draw the main menu
-func (g *Game) DrawMainMenu(imgOut *ebiten.Image) {
-	g.DrawSnake(g.SnakeBody, imgOut, 16, true)
-	op := ebiten.DrawImageOptions{}
-	op.ColorScale.Scale(0.7, 1.5, 2, 1)
-	imgOut.DrawImage(g.textSnake, &op)
-	txt := "UP/DOWN/LEFT/RIGHT: Change direction of snake"
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2, 180)
-	txt = "Q: Quit"
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2-12, 195)
-	txt = "SPACE: Start Game"
-	ebitenutil.DebugPrintAt(imgOut, txt, ((g.width*TILESIZE)/2-(len(txt)*6)/2)-6, 210)
-	txt = "Eat the cupcakes, but not yourself!"
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2, 265)
-	txt = "github.com/mikenye/snake"
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2, g.height*TILESIZE)
-}
-
-// draw the game over screen
-func (g *Game) DrawGameOverScreen(imgOut *ebiten.Image) {
-	txt := "GAME OVER!"
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2, 130)
-	txt = "SPACE: New Game"
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2, 195)
-	txt = "ESC: Main Menu"
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2+9, 210)
-	txt = "Q: Quit"
-	ebitenutil.DebugPrintAt(imgOut, txt, (g.width*TILESIZE)/2-(len(txt)*6)/2, 225)
-}
-
-// draw function, ebiten calls this every tick to render the screen
-func (g *Game) Draw(screen *ebiten.Image) {
-
-	switch g.state {
-
-	// main menu: draw the title screen
-	case StateMainMenu:
-		g.DrawMainMenu(screen)
-
-	// game start: draw the game screen with countdown overlay
-	case StateGameStart:
-		g.DrawFood(screen, 15, false)
-		g.DrawSnake(g.SnakeBody, screen, 15, false)
-		if g.countDownNum > 0 {
-			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d", g.countDownNum), 212, 135)
-		} else {
-			ebitenutil.DebugPrintAt(screen, "GO!", 206, 135)
-		}
-		g.DrawScoreBar(screen)
-
-	// in game: draw the game screen
-	case StateInGame:
-		g.DrawFood(screen, 15, false)
-		g.DrawSnake(g.SnakeBody, screen, 15, false)
-		g.DrawScoreBar(screen)
-
-	// in game: draw the game screen
-	case StateGameEnd:
-		g.DrawFood(screen, 15, false)
-		g.DrawSnake(g.SnakeBody, screen, 15, false)
-		g.DrawScoreBar(screen)
-
-	// in game: draw the game screen with game over overlay
-	case StateGameOver:
-		g.DrawFood(screen, 15, true)
-		g.DrawSnake(g.SnakeBody, screen, 15, true)
-		g.DrawScoreBar(screen)
-		g.DrawGameOverScreen(screen)
-	}
-}
-
-// layout function, called by Ebiten to size window & content
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	screenWidth, screenHeight := g.ScreenSize()
-	return screenWidth, screenHeight
-}
-
-// load images, called once on startup
-func (g *Game) LoadImages() error {
-	var (
-		r   *bytes.Reader
-		i   image.Image
-		err error
-	)
-
-	// snake head
-	r = bytes.NewReader(pngSnakeHead)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeHead = ebiten.NewImageFromImage(i)
-
-	// snake skeleton head
-	r = bytes.NewReader(pngSnakeSkeletonHead)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeSkeletonHead = ebiten.NewImageFromImage(i)
-
-	// snake body
-	r = bytes.NewReader(pngSnakeBody)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeBody = ebiten.NewImageFromImage(i)
-
-	// snake skeleton body
-	r = bytes.NewReader(pngSnakeSkeletonBody)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeSkeletonBody = ebiten.NewImageFromImage(i)
-
-	// snake tail
-	r = bytes.NewReader(pngSnakeTail)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeTail = ebiten.NewImageFromImage(i)
-
-	// snake skeleton tail
-	r = bytes.NewReader(pngSnakeSkeletonTail)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeSkeletonTail = ebiten.NewImageFromImage(i)
-
-	// snake bend
-	r = bytes.NewReader(pngSnakeBend)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeBend = ebiten.NewImageFromImage(i)
-
-	// snake skeleton bend
-	r = bytes.NewReader(pngSnakeSkeletonBend)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeSkeletonBend = ebiten.NewImageFromImage(i)
-
-	// food
-	r = bytes.NewReader(pngCupcake)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgFood = ebiten.NewImageFromImage(i)
-
-	// snake tongue
-	r = bytes.NewReader(pngSnakeTongue)
-	i, _, err = image.Decode(r)
-	if err != nil {
-		return err
-	}
-	g.ImgSnakeTongue = ebiten.NewImageFromImage(i)
-
-	// snake head tongue out (only tile not 16x16 - this is 16x32)
-	g.ImgSnakeHeadTongueOut = ebiten.NewImage(TILESIZE, 2*TILESIZE)
-	op := ebiten.DrawImageOptions{}
-	// draw tongue
-	g.ImgSnakeHeadTongueOut.DrawImage(g.ImgSnakeTongue, &op)
-	// draw head below tongue
-	op.GeoM.Translate(0, TILESIZE)
-	g.ImgSnakeHeadTongueOut.DrawImage(g.ImgSnakeHead, &op)
-
-	return nil
-}
-
-// return the size of the screen in pixels based on game width/height in tile spaces
-func (g *Game) ScreenSize() (w, h int) {
-	w = TILESIZE * g.width
-	h = TILESIZE * g.height
-	h += g.scoreBar.Bounds().Dy()
-	return w, h
+	g.ResizeView()
 }
 
 func (g *Game) ChangeState(s gameState) {
 	switch s {
 	case StateMainMenu:
-		g.Reset()
+		g.ghostMode = false
+		g.daily = nil
+		g.SetBoardSize(g.defaultWidth, g.defaultHeight)
+		g.rules = Rules{}
+		g.Reset(NewSeed())
 
 		// grow a random snake
 		for i := 0; i <= rand.Intn(100); i++ {
-			g.SnakeAdvance(g.SnakeBody, RandomSnakeDirection(g.SnakeBody.Head.facing))
+			g.SnakeAdvance(g.SnakeBody, RandomSnakeDirection(g.SnakeBody.Head().facing))
 		}
 
 		// fast movement speed for background snake
 		g.ticksPerMovement = 5
 
 	case StateGameStart:
-		g.Reset()
+		// racing the ghost: same seed as the ghost, so the same cupcakes
+		// daily challenge: the day's seed, board size & rules
+		// otherwise: the world size, board topology & tail rule chosen in the settings
+		seed := NewSeed()
+		switch {
+		case g.daily != nil:
+			seed = g.daily.Seed
+			g.SetBoardSize(g.daily.Width, g.daily.Height)
+			g.rules = g.daily.Rules
+		case g.ghostMode && g.best != nil:
+			seed = g.best.Seed
+		default:
+			scale := g.WorldSize().scale
+			g.SetBoardSize(g.defaultWidth*scale, g.defaultHeight*scale)
+			g.rules.Topology = g.settings.Topology
+			g.rules.TailBlocks = g.settings.TailBlocks
+		}
+		g.Reset(seed)
 	case StateInGame:
+		g.ticks = 0
+		g.replay = NewReplay(g.seed, g.width, g.height, g.rules)
 	case StateGameEnd:
+		g.StartShake(20)
 	case StateGameOver:
+	case StateGameWon:
+		g.Celebrate()
+	case StateSettings:
+		g.settingsCursor = 0
+	case StateReplayList:
+		g.SetBoardSize(g.defaultWidth, g.defaultHeight)
+		g.rules = Rules{}
+		g.OpenReplayList()
+	case StateReplayView:
+	case StateAchievements:
+	case StatePaused:
+	case StateQuitConfirm:
 	}
 	g.state = s
 }
 
-// set initial game state
-func (g *Game) Reset() {
+// set initial game state, with seed for the game's random number generator
+func (g *Game) Reset(seed uint64) {
 
-	g.ticksPerMovement = 30
+	g.ticksPerMovement = g.Speed().first
 	g.SnakeDirection = UP
 	g.countDownNum = 3
 	g.score = 0
+	g.scoring = Scoring{}
 	g.skeleTicks = 0
+	g.switchTicks = 0
+	g.ghost = nil
+	g.stats = GameStats{}
+	g.ClearEffects()
 
-	// init fresh snake body
+	// seed for food placement
+	g.seed = seed
+
+	// init fresh snake body, on an empty board
+	g.occupancy = NewOccupancy(g.width, g.height)
 	g.SnakeBody = g.SpawnSnake(g.width/2, g.height/2)
+	g.occupancy.AddSnake(g.SnakeBody)
+	g.ResetCamera()
 
 	// init food
 	g.SpawnFood()
 
 }
 
-// create a new game object
-func NewGame(width, height int) (*Game, error) {
-	g := Game{
-		width:                width,
-		height:               height,
-		skeleTicksPerSegment: 2,
-		tongueTicksMin:       20,
-	}
-
-	// load images
-	err := g.LoadImages()
-
-	// init score bar
-	g.scoreBar = ebiten.NewImage(g.width*g.ImgSnakeHead.Bounds().Dy(), 16)
-	g.scoreBar.Fill(color.RGBA{34, 32, 52, 255})
-
-	// init title screen
-	w, h := g.ScreenSize()
-	g.textSnake = ebiten.NewImage(w, h)
-	g.InitTitleScreen(g.textSnake)
-
-	// set initial game state
-	g.Reset()
-	g.ChangeState(StateMainMenu)
-
-	return &g, err
-}
-
 // return a random direction for the snake
 func RandomSnakeDirection(currentDirection snakeDirection) (d snakeDirection) {
 	// should we change direction?
@@ -1275,32 +681,17 @@ func RandomSnakeDirection(currentDirection snakeDirection) (d snakeDirection) {
 	}
 }
 
-// rotates a tile around its centre
-func RotateTile(img *ebiten.Image, op *ebiten.DrawImageOptions, rotation float64) {
-	op.GeoM.Translate(-TILESIZE/2, -TILESIZE/2)
-	op.GeoM.Rotate(rotation)
-	op.GeoM.Translate(TILESIZE/2, TILESIZE/2)
-}
-
-// main function
-func main() {
-	var err error
-
-	// create new game object
-	g, err := NewGame(27, 20)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// set up game window
-	screenWidth, screenHeight := g.ScreenSize()
-	ebiten.SetWindowSize(screenWidth*2, (screenHeight * 2))
-	ebiten.SetWindowTitle("Snake")
-
-	// start game
-	err = ebiten.RunGame(g)
-	if err != nil {
-		log.Fatal(err)
+// return the unit x/y step for direction d
+func DirectionDelta(d snakeDirection) (dx, dy int) {
+	switch d {
+	case UP:
+		dy = -1
+	case DOWN:
+		dy = 1
+	case LEFT:
+		dx = -1
+	case RIGHT:
+		dx = 1
 	}
-
+	return dx, dy
 }
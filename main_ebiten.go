@@ -0,0 +1,893 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// the parts of a game only needed to draw it in a window & read input: sprites, fonts, offscreen images & gamepads
+// (kept apart from Game's state, so the game can be built without ebiten; see headless.go)
+type gameView struct {
+
+	// sprite atlas
+	atlas *Atlas
+
+	// snake tiles
+
+	ImgSnakeHead          *ebiten.Image
+	ImgSnakeBody          *ebiten.Image
+	ImgSnakeTail          *ebiten.Image
+	ImgSnakeBend          *ebiten.Image
+	ImgSnakeTongue        *ebiten.Image
+	ImgSnakeHeadTongueOut *ebiten.Image
+
+	// snake skeleton
+
+	ImgSnakeSkeletonHead *ebiten.Image
+	ImgSnakeSkeletonBody *ebiten.Image
+	ImgSnakeSkeletonTail *ebiten.Image
+	ImgSnakeSkeletonBend *ebiten.Image
+
+	// food tile
+	ImgFood *ebiten.Image
+
+	// score bar
+	scoreBar *ebiten.Image
+
+	// title screen text
+	textSnake *ebiten.Image
+
+	// UI fonts
+	fonts *FontSources
+	font  text.Face
+
+	// whole frame is drawn here first, so it can be shaken & scaled
+	frame *ebiten.Image
+
+	// whole board is drawn here, and the camera's view of it drawn to the frame
+	world *ebiten.Image
+
+	// connected gamepads
+	gamepadIDs []ebiten.GamepadID
+}
+
+// draws the text SNAKE out of snakes
+func (g *Game) InitTitleScreen(imgOut *ebiten.Image) {
+	S := g.SpawnSnake(3, 1)
+	g.SnakeMove(S, LEFT, false, false)
+	g.SnakeMove(S, LEFT, false, false)
+	g.SnakeAdvance(S, DOWN)
+	g.SnakeAdvance(S, DOWN)
+	g.SnakeAdvance(S, RIGHT)
+	g.SnakeAdvance(S, RIGHT)
+	g.SnakeAdvance(S, DOWN)
+	g.SnakeAdvance(S, DOWN)
+	g.SnakeAdvance(S, DOWN)
+	g.SnakeAdvance(S, DOWN)
+	g.SnakeAdvance(S, LEFT)
+	g.SnakeAdvance(S, LEFT)
+	g.SnakeAdvance(S, LEFT)
+	g.SnakeAdvance(S, UP)
+	g.SnakeAdvance(S, RIGHT)
+	g.SnakeAdvance(S, RIGHT)
+	g.SnakeAdvance(S, UP)
+	g.SnakeAdvance(S, UP)
+	g.SnakeAdvance(S, LEFT)
+	g.SnakeAdvance(S, LEFT)
+	g.SnakeAdvance(S, UP)
+	g.SnakeAdvance(S, UP)
+	g.SnakeAdvance(S, UP)
+	g.SnakeAdvance(S, UP)
+	g.SnakeAdvance(S, RIGHT)
+	g.SnakeAdvance(S, RIGHT)
+	g.SnakeAdvance(S, RIGHT)
+
+	N := g.SpawnSnake(8, 7)
+	g.SnakeMove(N, UP, false, false)
+	g.SnakeMove(N, UP, false, false)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, LEFT)
+	g.SnakeAdvance(N, LEFT)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, LEFT)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, UP)
+	g.SnakeAdvance(N, RIGHT)
+	g.SnakeAdvance(N, RIGHT)
+	g.SnakeAdvance(N, RIGHT)
+	g.SnakeAdvance(N, RIGHT)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+	g.SnakeAdvance(N, DOWN)
+
+	A := g.SpawnSnake(14, 2)
+	g.SnakeMove(A, UP, false, false)
+	g.SnakeMove(A, LEFT, false, false)
+	g.SnakeAdvance(A, LEFT)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, LEFT)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, RIGHT)
+	g.SnakeAdvance(A, RIGHT)
+	g.SnakeAdvance(A, RIGHT)
+	g.SnakeAdvance(A, RIGHT)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, DOWN)
+	g.SnakeAdvance(A, LEFT)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, UP)
+	g.SnakeAdvance(A, LEFT)
+
+	K := g.SpawnSnake(18, 6)
+	g.SnakeMove(K, DOWN, false, false)
+	g.SnakeMove(K, LEFT, false, false)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, RIGHT)
+	g.SnakeAdvance(K, DOWN)
+	g.SnakeAdvance(K, DOWN)
+	g.SnakeAdvance(K, DOWN)
+	g.SnakeAdvance(K, DOWN)
+	g.SnakeAdvance(K, DOWN)
+	g.SnakeAdvance(K, RIGHT)
+	g.SnakeAdvance(K, RIGHT)
+	g.SnakeAdvance(K, DOWN)
+	g.SnakeAdvance(K, DOWN)
+	g.SnakeAdvance(K, RIGHT)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, LEFT)
+	g.SnakeAdvance(K, LEFT)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, RIGHT)
+	g.SnakeAdvance(K, RIGHT)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, UP)
+	g.SnakeAdvance(K, LEFT)
+	g.SnakeAdvance(K, DOWN)
+
+	E := g.SpawnSnake(26, 3)
+	g.SnakeMove(E, LEFT, false, false)
+	g.SnakeMove(E, LEFT, false, false)
+	g.SnakeAdvance(E, UP)
+	g.SnakeAdvance(E, UP)
+	g.SnakeAdvance(E, RIGHT)
+	g.SnakeAdvance(E, RIGHT)
+	g.SnakeAdvance(E, UP)
+	g.SnakeAdvance(E, LEFT)
+	g.SnakeAdvance(E, LEFT)
+	g.SnakeAdvance(E, LEFT)
+	g.SnakeAdvance(E, DOWN)
+	g.SnakeAdvance(E, DOWN)
+	g.SnakeAdvance(E, DOWN)
+	g.SnakeAdvance(E, DOWN)
+	g.SnakeAdvance(E, DOWN)
+	g.SnakeAdvance(E, DOWN)
+	g.SnakeAdvance(E, DOWN)
+	g.SnakeAdvance(E, RIGHT)
+	g.SnakeAdvance(E, RIGHT)
+	g.SnakeAdvance(E, RIGHT)
+	g.SnakeAdvance(E, UP)
+	g.SnakeAdvance(E, LEFT)
+	g.SnakeAdvance(E, LEFT)
+	g.SnakeAdvance(E, UP)
+	g.SnakeAdvance(E, UP)
+	g.SnakeAdvance(E, RIGHT)
+	g.SnakeAdvance(E, RIGHT)
+
+	g.DrawSnake(S, imgOut, 0, false, 1)
+	g.DrawSnake(N, imgOut, 0, false, 1)
+	g.DrawSnake(A, imgOut, 0, false, 1)
+	g.DrawSnake(K, imgOut, 0, false, 1)
+	g.DrawSnake(E, imgOut, 0, false, 1)
+}
+
+// draw the food tile, offsetting by yOffset (for score bar)
+func (g *Game) DrawFood(imgOut *ebiten.Image, yOffset int, dimmed bool) {
+	op := ebiten.DrawImageOptions{}
+	xpos := g.food.x * g.ImgFood.Bounds().Dx()
+	ypos := g.food.y * g.ImgFood.Bounds().Dy()
+
+	// translate
+	op.GeoM.Translate(float64(xpos), float64(ypos+yOffset))
+
+	// if game over, fade slightly
+	if dimmed {
+		g.DimTile(&op)
+	}
+	g.DrawTileOutline(imgOut, g.ImgFood, &op)
+	imgOut.DrawImage(g.ImgFood, &op)
+}
+
+// draw the snake, offsetting by yOffset (for score bar)
+// progress is how far the snake is from the cells it has just left (0) to its cells (1), used to interpolate the head & tail
+func (g *Game) DrawSnake(SnakeBody *SnakeBody, imgOut *ebiten.Image, yOffset int, dimmed bool, progress float64) {
+	var img *ebiten.Image
+	op := ebiten.DrawImageOptions{}
+
+	// tail first, so the head is drawn over the neck as it slides out of it
+	for i := SnakeBody.length - 1; i >= 0; i-- {
+		seg := SnakeBody.Segment(i)
+		op.GeoM.Reset()
+		op.ColorScale.Reset()
+
+		// tile sprite, from the segments either side
+		tile := SnakeBody.Tile(i)
+		switch tile.Kind {
+		case SegmentHead:
+			if seg.skeleton {
+				img = g.ImgSnakeSkeletonHead
+			} else {
+				if g.tongueShow {
+					img = g.ImgSnakeHeadTongueOut
+					// as tongue out tile is 32 px high, we need to move up by 16px so the rest of the transforms/rotations work as expected
+					op.GeoM.Translate(0, -TILESIZE)
+				} else {
+					img = g.ImgSnakeHead
+				}
+			}
+
+		case SegmentBody:
+			if seg.skeleton {
+				img = g.ImgSnakeSkeletonBody
+			} else {
+				img = g.ImgSnakeBody
+			}
+
+		case SegmentBend:
+			if seg.skeleton {
+				img = g.ImgSnakeSkeletonBend
+			} else {
+				img = g.ImgSnakeBend
+			}
+
+		case SegmentTail:
+			if seg.skeleton {
+				img = g.ImgSnakeSkeletonTail
+			} else {
+				img = g.ImgSnakeTail
+			}
+		}
+		rotation := float64(tile.Turns) * math.Pi / 2
+
+		// get pixel position for segment
+		xpos := float64(seg.x * TILESIZE)
+		ypos := float64(seg.y * TILESIZE)
+
+		// smooth movement: the head slides in from the cell it has just left, and so does the tail
+		// (unless the snake grew, leaving the tail where it was)
+		if i == 0 || i == SnakeBody.length-1 && !SnakeBody.grew {
+			dx, dy := DirectionDelta(seg.facing)
+			xpos -= float64(dx*TILESIZE) * (1 - progress)
+			ypos -= float64(dy*TILESIZE) * (1 - progress)
+		}
+
+		// if game over, fade slightly
+		if dimmed {
+			g.DimTile(&op)
+		}
+
+		// draw tile (twice if it is part way across the edge of the board), rotated & mirrored
+		for _, pos := range g.WrappedPositions(xpos, ypos) {
+			tileOp := op
+			RotateTile(&tileOp, rotation, tile.Mirrored != pos.flipped)
+			tileOp.GeoM.Translate(pos.x, pos.y+float64(yOffset))
+			if i == 0 && !seg.skeleton {
+				g.DrawTileOutline(imgOut, img, &tileOp)
+			}
+			imgOut.DrawImage(img, &tileOp)
+		}
+	}
+}
+
+// update function for when in game
+func (g *Game) UpdateInGame() error {
+
+	// pause
+	if g.PauseJustPressed() {
+		g.ChangeState(StatePaused)
+		return nil
+	}
+
+	// handle input
+	g.HandleInGameInput()
+
+	// move the snake (and the ghost), saving the replay if it dies or fills the board
+	// (before the game over event, so achievements see the daily challenge result the replay records)
+	g.StepInGame()
+	g.UpdateGhost()
+	if g.state == StateGameEnd || g.state == StateGameWon {
+		g.SaveReplay()
+		g.AchievementEvent(EventGameOver)
+	}
+
+	// random snake tongue
+	g.RandomSnakeTongue()
+
+	// camera follows the snake
+	g.UpdateCamera()
+
+	return nil
+}
+
+// update function for when in game over state
+func (g *Game) UpdateGameOver() error {
+	// handle input
+	switch {
+	case ebiten.IsKeyPressed(ebiten.KeySpace):
+		g.ChangeState(StateGameStart)
+	case ebiten.IsKeyPressed(ebiten.KeyEscape):
+		g.ChangeState(StateMainMenu)
+	case inpututil.IsKeyJustPressed(ebiten.KeyE) && g.daily != nil:
+		g.ShareDailyResult()
+	}
+	return nil
+}
+
+// update main menu, move random background snake
+func (g *Game) UpdateMainMenu() error {
+
+	// press space to start, C to continue the saved game, G to race the ghost, D for the daily challenge, S for settings, R for replays
+	switch {
+	case ebiten.IsKeyPressed(ebiten.KeySpace):
+		g.ChangeState(StateGameStart)
+	case inpututil.IsKeyJustPressed(ebiten.KeyC) && g.saved != nil:
+		g.ContinueGame()
+	case inpututil.IsKeyJustPressed(ebiten.KeyG) && g.best != nil:
+		g.ghostMode = true
+		g.ChangeState(StateGameStart)
+	case inpututil.IsKeyJustPressed(ebiten.KeyD):
+		daily := DailyChallenge(time.Now())
+		g.daily = &daily
+		g.ChangeState(StateGameStart)
+	case inpututil.IsKeyJustPressed(ebiten.KeyS):
+		g.ChangeState(StateSettings)
+	case inpututil.IsKeyJustPressed(ebiten.KeyR):
+		g.ChangeState(StateReplayList)
+	case inpututil.IsKeyJustPressed(ebiten.KeyA):
+		g.ChangeState(StateAchievements)
+	}
+
+	// movement speed & random direction
+	g.ticks++
+	if g.ticks >= g.ticksPerMovement {
+		g.ticks = 0
+		g.SnakeMove(g.SnakeBody, RandomSnakeDirection(g.SnakeBody.Head().facing), false, false)
+	}
+
+	// random snake tongue
+	g.RandomSnakeTongue()
+
+	return nil
+}
+
+// update function, ebiten calls this every tick (60 times per second)
+func (g *Game) Update() error {
+	var err error
+
+	// Press Q (or close the window) to quit regardless of state, asking first if in game
+	err = g.UpdateQuit()
+	if err != nil {
+		return err
+	}
+
+	// fullscreen toggle, remember window size & position
+	g.UpdateWindow()
+
+	// screenshot & GIF capture keys
+	g.UpdateCapture()
+
+	// particles, pop-ups & screen shake
+	g.UpdateEffects()
+
+	// achievement toasts
+	g.UpdateToasts()
+
+	switch g.state {
+
+	// main menu
+	case StateMainMenu:
+		err = g.UpdateMainMenu()
+
+	// game start (countdown)
+	case StateGameStart:
+		err = g.UpdateGameStart()
+
+	// in-game (user controls snake)
+	case StateInGame:
+		err = g.UpdateInGame()
+
+	// end game (turn to skeleton)
+	case StateGameEnd:
+		err = g.UpdateEndGame()
+
+	// game over (game over screen), or perfect game
+	case StateGameOver, StateGameWon:
+		err = g.UpdateGameOver()
+
+	// settings screen
+	case StateSettings:
+		err = g.UpdateSettings()
+
+	// replay list
+	case StateReplayList:
+		err = g.UpdateReplayList()
+
+	// watching a replay
+	case StateReplayView:
+		err = g.UpdateReplayView()
+
+	// achievements screen
+	case StateAchievements:
+		err = g.UpdateAchievements()
+
+	// paused (pause menu)
+	case StatePaused:
+		err = g.UpdatePaused()
+
+	// asking whether to quit
+	case StateQuitConfirm:
+		err = g.UpdateQuitConfirm()
+	}
+
+	return err
+}
+
+// draw the score bar at the top of the screen
+func (g *Game) DrawScoreBar(imgOut *ebiten.Image) {
+	imgOut.DrawImage(g.scoreBar, &ebiten.DrawImageOptions{})
+	txt := g.T("game.calories", g.scoring.Breakdown.Total())
+	g.DrawTextCentred(imgOut, txt, float64(g.scoreBar.Bounds().Dy()-FONTSIZE)/2, TextStyle{})
+	g.DrawGhostIndicator(imgOut)
+	g.DrawCombo(imgOut)
+}
+
+// draw the main menu
+func (g *Game) DrawMainMenu(imgOut *ebiten.Image) {
+	g.DrawSnake(g.SnakeBody, imgOut, 16, true, g.MovementProgress())
+	op := ebiten.DrawImageOptions{}
+	op.ColorScale.Scale(0.7, 1.5, 2, 1)
+	imgOut.DrawImage(g.textSnake, &op)
+	w, _ := g.ScreenSize()
+	keys := [][2]string{
+		g.ControlsHelp(),
+		{g.T("key.space"), g.T("menu.start")},
+	}
+	if g.saved != nil {
+		keys = append(keys, [2]string{g.T("key.c"), g.T("menu.continue")})
+	}
+	if g.best != nil {
+		keys = append(keys, [2]string{g.T("key.g"), g.T("menu.ghost")})
+	}
+	daily := g.T("menu.daily")
+	if streak := g.dailyResults.Streak(DailyChallenge(time.Now()).Date); streak > 0 {
+		daily = g.T("menu.daily_streak", streak)
+	}
+	keys = append(keys, [2]string{g.T("key.d"), daily})
+	keys = append(keys, [][2]string{
+		{g.T("key.s"), g.T("menu.settings")},
+		{g.T("key.r"), g.T("menu.replays")},
+		{g.T("key.a"), g.T("menu.achievements")},
+		{g.T("key.f11"), g.T("menu.fullscreen")},
+		{g.T("key.q"), g.T("menu.quit")},
+	}...)
+	g.DrawKeyHelp(imgOut, 163, keys, TextStyle{Outline: textOutlineColour})
+	g.DrawTextCentred(imgOut, g.T("menu.tagline"), 302, TextStyle{
+		Colour:    textHighlightColour,
+		Outline:   textOutlineColour,
+		WrapWidth: float64(w - 2*TILESIZE),
+	})
+	g.DrawTextCentred(imgOut, "github.com/mikenye/snake", float64(g.height*TILESIZE)+4, TextStyle{Outline: textOutlineColour})
+}
+
+// draw the game over (or perfect game) screen
+// (positioned relative to the middle of the screen, as daily challenge boards vary in size)
+func (g *Game) DrawGameOverScreen(imgOut *ebiten.Image) {
+	_, h := g.ScreenSize()
+	mid := float64(h) / 2
+	title := g.T("gameover.title")
+	if g.state == StateGameWon {
+		title = g.T("gameover.perfect")
+	}
+	g.DrawTextCentred(imgOut, title, mid-84, TextStyle{Colour: textHighlightColour, Outline: textOutlineColour})
+	g.DrawScoreBreakdown(imgOut, mid-64)
+	keys := [][2]string{
+		{g.T("key.space"), g.T("gameover.new_game")},
+		{g.T("key.esc"), g.T("gameover.main_menu")},
+		{g.T("key.q"), g.T("menu.quit")},
+	}
+
+	// daily challenge: the day's best score, and sharing the result
+	if g.daily != nil {
+		g.DrawTextCentred(imgOut, g.T("daily.best", g.dailyResults[g.daily.Date].Points), mid+12, TextStyle{Outline: textOutlineColour})
+		keys = append(keys, [2]string{g.T("key.e"), g.T("daily.share_result")})
+	}
+	g.DrawKeyHelp(imgOut, mid+30, keys, TextStyle{Outline: textOutlineColour})
+}
+
+// draw function, ebiten calls this every tick to render the screen
+func (g *Game) Draw(screen *ebiten.Image) {
+
+	// draw the frame offscreen first, so it can be shaken
+	frame := g.frame
+	frame.Fill(color.Black)
+
+	switch g.state {
+
+	// main menu: draw the title screen
+	case StateMainMenu:
+		g.DrawMainMenu(frame)
+
+	// game start: draw the game screen with countdown overlay
+	case StateGameStart:
+		world := g.World()
+		g.DrawWalls(world, 0)
+		g.DrawFood(world, 0, false)
+		g.DrawSnake(g.SnakeBody, world, 0, false, g.MovementProgress())
+		g.DrawView(frame, 15)
+		txt := g.T("game.go")
+		if g.countDownNum > 0 {
+			txt = fmt.Sprintf("%d", g.countDownNum)
+		}
+		_, h := g.ScreenSize()
+		g.DrawTextCentred(frame, txt, float64(h)/2-33, TextStyle{Colour: textHighlightColour, Outline: textOutlineColour})
+		g.DrawScoreBar(frame)
+
+	// in game: draw the game screen
+	case StateInGame:
+		world := g.World()
+		g.DrawWalls(world, 0)
+		g.DrawGhost(world, 0)
+		g.DrawFood(world, 0, false)
+		g.DrawSnake(g.SnakeBody, world, 0, false, g.MovementProgress())
+		g.DrawEffects(world, 0)
+		g.DrawView(frame, 15)
+		g.DrawScoreBar(frame)
+
+	// in game: draw the game screen
+	case StateGameEnd:
+		world := g.World()
+		g.DrawWalls(world, 0)
+		g.DrawGhost(world, 0)
+		g.DrawFood(world, 0, false)
+		g.DrawSnake(g.SnakeBody, world, 0, false, g.MovementProgress())
+		g.DrawEffects(world, 0)
+		g.DrawView(frame, 15)
+		g.DrawScoreBar(frame)
+
+	// in game: draw the game screen with game over overlay
+	case StateGameOver:
+		world := g.World()
+		g.DrawWalls(world, 0)
+		g.DrawGhost(world, 0)
+		g.DrawFood(world, 0, true)
+		g.DrawSnake(g.SnakeBody, world, 0, true, g.MovementProgress())
+		g.DrawEffects(world, 0)
+		g.DrawView(frame, 15)
+		g.DrawScoreBar(frame)
+		g.DrawGameOverScreen(frame)
+
+	// perfect game: draw the game screen (the full board) with the perfect game overlay
+	case StateGameWon:
+		world := g.World()
+		g.DrawWalls(world, 0)
+		g.DrawGhost(world, 0)
+		g.DrawSnake(g.SnakeBody, world, 0, false, g.MovementProgress())
+		g.DrawEffects(world, 0)
+		g.DrawView(frame, 15)
+		g.DrawScoreBar(frame)
+		g.DrawGameOverScreen(frame)
+
+	// settings: draw the settings screen over the background snake
+	case StateSettings:
+		g.DrawSnake(g.SnakeBody, frame, 16, true, g.MovementProgress())
+		g.DrawSettings(frame)
+
+	// replay list: draw the list over the background snake
+	case StateReplayList:
+		g.DrawSnake(g.SnakeBody, frame, 16, true, g.MovementProgress())
+		g.DrawReplayList(frame)
+
+	// watching a replay: draw the replay's game with the timeline
+	case StateReplayView:
+		g.DrawReplayView(frame)
+
+	// achievements: draw the list over the background snake
+	case StateAchievements:
+		g.DrawSnake(g.SnakeBody, frame, 16, true, g.MovementProgress())
+		g.DrawAchievements(frame)
+
+	// paused, or asking whether to quit: draw the game screen dimmed, with the pause menu or quit confirmation over it
+	case StatePaused, StateQuitConfirm:
+		world := g.World()
+		g.DrawWalls(world, 0)
+		g.DrawGhost(world, 0)
+		g.DrawFood(world, 0, true)
+		g.DrawSnake(g.SnakeBody, world, 0, true, g.MovementProgress())
+		g.DrawView(frame, 15)
+		g.DrawScoreBar(frame)
+		if g.state == StatePaused {
+			g.DrawPauseMenu(frame)
+		} else {
+			g.DrawQuitConfirm(frame)
+		}
+	}
+
+	// achievement toast
+	g.DrawToast(frame)
+
+	// screenshot & GIF capture (before the capture message, so it isn't captured)
+	g.CaptureFrame(frame)
+	g.DrawCaptureMessage(frame)
+
+	// draw the frame to the screen: shaken if needed, scaled up & letterboxed
+	rect, scale := g.FrameRect(screen.Bounds().Dx(), screen.Bounds().Dy())
+	op := ebiten.DrawImageOptions{}
+	op.GeoM.Translate(g.ShakeOffset())
+	op.GeoM.Scale(float64(scale), float64(scale))
+	op.GeoM.Translate(float64(rect.Min.X), float64(rect.Min.Y))
+	screen.SubImage(rect).(*ebiten.Image).DrawImage(frame, &op)
+}
+
+// layout function, called by Ebiten to size window & content
+// the screen matches the window's size in device pixels, and the game frame is scaled up to fit in Draw
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	s := ebiten.Monitor().DeviceScaleFactor()
+	g.screenWidth = int(math.Ceil(float64(outsideWidth) * s))
+	g.screenHeight = int(math.Ceil(float64(outsideHeight) * s))
+	return g.screenWidth, g.screenHeight
+}
+
+// load images, called once on startup
+func (g *Game) LoadImages() error {
+	var err error
+
+	// sprite atlas
+	g.atlas, err = LoadAtlas(pngAtlas, jsonAtlas)
+	if err != nil {
+		return err
+	}
+	return g.LoadSprites()
+}
+
+// get sprites from the atlas, called on startup & when the atlas is remapped
+func (g *Game) LoadSprites() error {
+	var err error
+
+	// sprites by atlas region name
+	sprites := []struct {
+		name string
+		img  **ebiten.Image
+	}{
+		{"snakehead", &g.ImgSnakeHead},
+		{"snakebody", &g.ImgSnakeBody},
+		{"snaketail", &g.ImgSnakeTail},
+		{"snakebend", &g.ImgSnakeBend},
+		{"snaketongue", &g.ImgSnakeTongue},
+		{"snakeskeletonhead", &g.ImgSnakeSkeletonHead},
+		{"snakeskeletonbody", &g.ImgSnakeSkeletonBody},
+		{"snakeskeletontail", &g.ImgSnakeSkeletonTail},
+		{"snakeskeletonbend", &g.ImgSnakeSkeletonBend},
+		{"cupcake", &g.ImgFood},
+	}
+	for _, s := range sprites {
+		*s.img, err = g.atlas.Image(s.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	// snake tongue animation: frame 0 is tongue in, frame 1 is tongue out
+	// (tongue out is the only tile not 16x16 - this is 16x32)
+	frames, err := g.atlas.Frames("snaketongue")
+	if err != nil {
+		return err
+	}
+	if len(frames) != 2 {
+		return fmt.Errorf("atlas animation %q has %d frames, expected 2", "snaketongue", len(frames))
+	}
+	g.ImgSnakeHeadTongueOut = frames[1]
+
+	return nil
+}
+
+// return the size of the screen in pixels based on the view's width/height in tile spaces
+func (g *Game) ScreenSize() (w, h int) {
+	w, h = g.ViewSize()
+	w *= TILESIZE
+	h *= TILESIZE
+	h += g.scoreBar.Bounds().Dy()
+	return w, h
+}
+
+// create a new game object
+func NewGame(width, height int, settings Settings) (*Game, error) {
+	g := Game{
+		width:                width,
+		height:               height,
+		defaultWidth:         width,
+		defaultHeight:        height,
+		settings:             settings,
+		capture:              NewRecorder(),
+		skeleTicksPerSegment: 2,
+		tongueTicksMin:       20,
+	}
+
+	// load images
+	err := g.LoadImages()
+	if err != nil {
+		return nil, err
+	}
+
+	// load fonts & message catalogues, and set language
+	g.fonts, err = LoadFontSources()
+	if err != nil {
+		return nil, err
+	}
+	g.catalogues, err = LoadCatalogues()
+	if err != nil {
+		return nil, err
+	}
+	err = g.SetLanguage(g.settings.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	// init score bar
+	g.scoreBar = ebiten.NewImage(g.width*g.ImgSnakeHead.Bounds().Dy(), 16)
+
+	// init title screen
+	w, h := g.ScreenSize()
+	g.textSnake = ebiten.NewImage(w, h)
+
+	// apply colour palette (also draws score bar & title screen)
+	err = g.ApplyPalette()
+	if err != nil {
+		return nil, err
+	}
+
+	// init offscreen frame & board
+	g.frame = ebiten.NewImage(w, h)
+	g.world = ebiten.NewImage(g.width*TILESIZE, g.height*TILESIZE)
+
+	// best replay, for the ghost race
+	g.LoadBestReplay()
+
+	// achievement progress
+	g.achievements, err = LoadAchievements()
+	if err != nil {
+		log.Printf("could not load achievements: %v", err)
+	}
+
+	// daily challenge results
+	g.dailyResults, err = LoadDailyResults()
+	if err != nil {
+		log.Printf("could not load daily challenge results: %v", err)
+	}
+
+	// game saved to be resumed
+	g.saved, err = LoadSavedGame()
+	if err != nil {
+		log.Printf("could not load saved game: %v", err)
+	}
+
+	// set initial game state
+	g.Reset(NewSeed())
+	g.ChangeState(StateMainMenu)
+
+	return &g, nil
+}
+
+// rotates a tile around its centre, mirroring it left to right first if mirrored is true
+func RotateTile(op *ebiten.DrawImageOptions, rotation float64, mirrored bool) {
+	op.GeoM.Translate(-TILESIZE/2, -TILESIZE/2)
+	if mirrored {
+		op.GeoM.Scale(-1, 1)
+	}
+	op.GeoM.Rotate(rotation)
+	op.GeoM.Translate(TILESIZE/2, TILESIZE/2)
+}
+
+// main function
+func main() {
+	var err error
+
+	// "snake render ..." renders a replay without opening a window
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		err = RenderCommand(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// load settings (falling back to defaults if they can't be read)
+	settings, err := LoadSettings()
+	if err != nil {
+		log.Printf("could not load settings: %v", err)
+	}
+
+	// create new game object
+	g, err := NewGame(27, 20, settings)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// set up game window
+	g.SetupWindow()
+
+	// start game (quitting ends it with ebiten.Termination, which RunGame returns as nil)
+	err = ebiten.RunGame(g)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+}
+
+// resize the score bar & offscreen images to suit the board's size
+// (games without a window, like NewHeadlessGame's, have none to resize)
+func (g *Game) ResizeView() {
+	if g.scoreBar == nil {
+		return
+	}
+	w, _ := g.ViewSize()
+	g.scoreBar.Deallocate()
+	g.scoreBar = ebiten.NewImage(w*TILESIZE, TILESIZE)
+	g.scoreBar.Fill(g.PaletteColour(colourDark))
+	g.frame.Deallocate()
+	g.frame = ebiten.NewImage(g.ScreenSize())
+	g.world.Deallocate()
+	g.world = ebiten.NewImage(g.width*TILESIZE, g.height*TILESIZE)
+}
@@ -0,0 +1,113 @@
+package main
+
+// which cells of the board are occupied by snakes, kept up to date as snakes move,
+// so collision checks and counting free cells (for food) take constant time
+type Occupancy struct {
+
+	// size of the board
+	width, height int
+
+	// snake occupying each cell (nil if free), indexed by y*width+x
+	snakes []*SnakeBody
+
+	// number of segments in each cell (snakes not checked for death can overlap themselves)
+	counts []int
+
+	// number of free cells
+	free int
+}
+
+// return a new, empty occupancy grid for a board of width x height
+func NewOccupancy(width, height int) *Occupancy {
+	o := Occupancy{
+		width:  width,
+		height: height,
+		snakes: make([]*SnakeBody, width*height),
+		counts: make([]int, width*height),
+		free:   width * height,
+	}
+	return &o
+}
+
+// return the cell index of board position x, y, and whether it is on the board
+func (o *Occupancy) cell(x, y int) (int, bool) {
+	if x < 0 || y < 0 || x >= o.width || y >= o.height {
+		return 0, false
+	}
+	return y*o.width + x, true
+}
+
+// mark board position x, y as occupied by a segment of snake s
+// (positions off the board are ignored)
+func (o *Occupancy) Add(s *SnakeBody, x, y int) {
+	c, ok := o.cell(x, y)
+	if !ok {
+		return
+	}
+	o.snakes[c] = s
+	o.counts[c]++
+	if o.counts[c] == 1 {
+		o.free--
+	}
+}
+
+// mark a segment at board position x, y as gone
+// (positions off the board are ignored)
+func (o *Occupancy) Remove(x, y int) {
+	c, ok := o.cell(x, y)
+	if !ok || o.counts[c] == 0 {
+		return
+	}
+	o.counts[c]--
+	if o.counts[c] > 0 {
+		return
+	}
+	o.snakes[c] = nil
+	o.free++
+}
+
+// put every segment of snake s on the board, and keep it up to date as s moves
+func (o *Occupancy) AddSnake(s *SnakeBody) {
+	s.board = o
+	for i := 0; i < s.length; i++ {
+		seg := s.Segment(i)
+		o.Add(s, seg.x, seg.y)
+	}
+}
+
+// return the snake occupying board position x, y (nil if none), and how many of its segments are there
+func (o *Occupancy) At(x, y int) (*SnakeBody, int) {
+	c, ok := o.cell(x, y)
+	if !ok {
+		return nil, 0
+	}
+	return o.snakes[c], o.counts[c]
+}
+
+// is board position x, y occupied?
+func (o *Occupancy) Occupied(x, y int) bool {
+	_, n := o.At(x, y)
+	return n > 0
+}
+
+// return the number of free cells
+func (o *Occupancy) FreeCells() int {
+	return o.free
+}
+
+// return the board position of free cell i (0 to FreeCells()-1), counting across each row from the top
+// the order only depends on which cells are free, not how they came to be, so a resumed game (with its
+// occupancy rebuilt from the board) places food exactly as the game it was saved from
+// takes time in proportion to the board size, so it's only for when the board is nearly full
+func (o *Occupancy) FreeCell(i int) (x, y int) {
+	for c, n := range o.counts {
+		if n > 0 {
+			continue
+		}
+		if i == 0 {
+			return c % o.width, c / o.width
+		}
+		i--
+	}
+	return -1, -1
+}
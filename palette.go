@@ -0,0 +1,146 @@
+package main
+
+import (
+	"image/color"
+)
+
+// a colour palette, remapping the colours used by the sprites & UI
+type Palette struct {
+
+	// settings value for the palette
+	id string
+
+	// message key of the palette's name
+	name string
+
+	// colour remappings (colours not listed are left unchanged)
+	colours map[color.RGBA]color.RGBA
+
+	// colour of the outline drawn around the snake's head & the food (if enabled)
+	outline color.RGBA
+
+	// dim by darkening rather than fading, so tiles keep their contrast with the background
+	dimDarken bool
+}
+
+// source colours used by the sprites & UI
+var (
+	// snake body
+	colourSnakeGreen  = color.RGBA{75, 105, 47, 255}
+	colourSnakeRed    = color.RGBA{172, 50, 50, 255}
+	colourSnakeYellow = color.RGBA{251, 242, 54, 255}
+
+	// snake tongue
+	colourTongueRed1 = color.RGBA{166, 3, 3, 255}
+	colourTongueRed2 = color.RGBA{157, 30, 30, 255}
+	colourTongueRed3 = color.RGBA{131, 50, 50, 255}
+	colourTongueRed4 = color.RGBA{153, 50, 50, 255}
+
+	// cupcake (icing, cake & case), also skeleton
+	colourIcing = color.RGBA{255, 255, 255, 255}
+	colourCake  = color.RGBA{217, 160, 102, 255}
+	colourCase  = color.RGBA{238, 195, 154, 255}
+
+	// score bar & text outline
+	colourDark = color.RGBA{34, 32, 52, 255}
+)
+
+// available palettes, in the order they are offered on the settings screen
+var palettes = []Palette{
+	{
+		id:      "normal",
+		name:    "palette.normal",
+		outline: color.RGBA{255, 255, 255, 255},
+	},
+	{
+		// red/green: swap the snake's green & red to blue & orange
+		id:   "deuteranopia",
+		name: "palette.deuteranopia",
+		colours: map[color.RGBA]color.RGBA{
+			colourSnakeGreen:  {0, 114, 178, 255},
+			colourSnakeRed:    {230, 159, 0, 255},
+			colourSnakeYellow: {240, 228, 66, 255},
+			colourTongueRed1:  {213, 94, 0, 255},
+			colourTongueRed2:  {213, 94, 0, 255},
+			colourTongueRed3:  {180, 80, 0, 255},
+			colourTongueRed4:  {180, 80, 0, 255},
+		},
+		outline: color.RGBA{255, 255, 255, 255},
+	},
+	{
+		// red/green, with reds appearing darker: as above with brighter oranges
+		id:   "protanopia",
+		name: "palette.protanopia",
+		colours: map[color.RGBA]color.RGBA{
+			colourSnakeGreen:  {0, 114, 178, 255},
+			colourSnakeRed:    {255, 190, 60, 255},
+			colourSnakeYellow: {240, 240, 120, 255},
+			colourTongueRed1:  {255, 140, 30, 255},
+			colourTongueRed2:  {255, 140, 30, 255},
+			colourTongueRed3:  {230, 120, 20, 255},
+			colourTongueRed4:  {230, 120, 20, 255},
+		},
+		outline: color.RGBA{255, 255, 255, 255},
+	},
+	{
+		// blue/yellow: move the snake's yellow to pink & green to dark teal
+		id:   "tritanopia",
+		name: "palette.tritanopia",
+		colours: map[color.RGBA]color.RGBA{
+			colourSnakeGreen:  {0, 90, 90, 255},
+			colourSnakeRed:    {204, 30, 30, 255},
+			colourSnakeYellow: {255, 180, 190, 255},
+		},
+		outline: color.RGBA{255, 255, 255, 255},
+	},
+	{
+		// high contrast: white & yellow snake, magenta & cyan food, black UI
+		id:   "highcontrast",
+		name: "palette.highcontrast",
+		colours: map[color.RGBA]color.RGBA{
+			colourSnakeGreen:  {255, 255, 255, 255},
+			colourSnakeRed:    {128, 128, 128, 255},
+			colourSnakeYellow: {255, 255, 0, 255},
+			colourTongueRed1:  {255, 0, 0, 255},
+			colourTongueRed2:  {255, 0, 0, 255},
+			colourTongueRed3:  {255, 0, 0, 255},
+			colourTongueRed4:  {255, 0, 0, 255},
+			colourIcing:       {255, 0, 255, 255},
+			colourCake:        {0, 255, 255, 255},
+			colourCase:        {0, 200, 255, 255},
+			colourDark:        {0, 0, 0, 255},
+		},
+		outline:   color.RGBA{255, 255, 0, 255},
+		dimDarken: true,
+	},
+}
+
+// return the current palette
+func (g *Game) Palette() Palette {
+	for _, p := range palettes {
+		if p.id == g.settings.Palette {
+			return p
+		}
+	}
+	return palettes[0]
+}
+
+// remap colour c (premultiplied) through the current palette
+func (g *Game) PaletteColour(c color.RGBA) color.RGBA {
+	if c.A == 0 {
+		return c
+	}
+
+	// palettes map opaque colours, so un-premultiply to look up
+	key := color.RGBA{
+		R: uint8(uint16(c.R) * 255 / uint16(c.A)),
+		G: uint8(uint16(c.G) * 255 / uint16(c.A)),
+		B: uint8(uint16(c.B) * 255 / uint16(c.A)),
+		A: 255,
+	}
+	mapped, ok := g.Palette().colours[key]
+	if !ok {
+		return c
+	}
+	return fadeColour(mapped, float64(c.A)/255)
+}
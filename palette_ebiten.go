@@ -0,0 +1,54 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+)
+
+// apply the current palette to all tiles & UI images
+func (g *Game) ApplyPalette() error {
+	g.atlas.Remap(g.PaletteColour)
+	err := g.LoadSprites()
+	if err != nil {
+		return err
+	}
+
+	// score bar
+	g.scoreBar.Fill(g.PaletteColour(colourDark))
+
+	// title screen text
+	g.textSnake.Clear()
+	g.InitTitleScreen(g.textSnake)
+
+	return nil
+}
+
+// dim a tile being drawn (eg: the game board behind the game over screen)
+func (g *Game) DimTile(op *ebiten.DrawImageOptions) {
+	if g.Palette().dimDarken {
+		op.ColorScale.Scale(0.5, 0.5, 0.5, 1)
+	} else {
+		op.ColorScale.ScaleAlpha(0.5)
+	}
+}
+
+// draw an outline around tile img, which is then drawn with op
+func (g *Game) DrawTileOutline(imgOut, img *ebiten.Image, op *ebiten.DrawImageOptions) {
+	if !g.settings.Outline {
+		return
+	}
+
+	// draw a silhouette of the tile in the outline colour, offset by a pixel in each direction
+	outline := g.Palette().outline
+	cm := colorm.ColorM{}
+	cm.Scale(0, 0, 0, 1)
+	cm.Translate(float64(outline.R)/255, float64(outline.G)/255, float64(outline.B)/255, 0)
+	cop := colorm.DrawImageOptions{}
+	for _, d := range [][2]float64{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		cop.GeoM = op.GeoM
+		cop.GeoM.Translate(d[0], d[1])
+		colorm.DrawImage(imgOut, img, cm, &cop)
+	}
+}
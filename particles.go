@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// colours for particle effects
+var (
+	// cupcake crumbs
+	crumbColours = []color.RGBA{
+		{217, 87, 99, 255},
+		{238, 195, 154, 255},
+		{143, 86, 59, 255},
+		{255, 255, 255, 255},
+	}
+
+	// dust kicked up when turning
+	dustColours = []color.RGBA{
+		{132, 126, 135, 255},
+		{105, 106, 106, 255},
+	}
+
+	// skeleton bone fragments
+	boneColours = []color.RGBA{
+		{255, 255, 255, 255},
+		{203, 219, 252, 255},
+		{155, 173, 183, 255},
+	}
+
+	// perfect game confetti
+	confettiColours = []color.RGBA{
+		{251, 242, 54, 255},
+		{217, 87, 99, 255},
+		{99, 155, 255, 255},
+		{106, 190, 48, 255},
+	}
+)
+
+// a single particle
+type Particle struct {
+
+	// position (in pixels, relative to the board)
+	x, y float64
+
+	// velocity (in pixels per tick)
+	vx, vy float64
+
+	// added to vy every tick
+	gravity float64
+
+	// size (in pixels)
+	size float64
+
+	// ticks remaining & ticks at spawn
+	life, maxLife int
+
+	// colour of particle
+	colour color.RGBA
+}
+
+// a score pop-up, floating up from eaten food
+type ScorePopup struct {
+
+	// position (in pixels, relative to the board)
+	x, y float64
+
+	// ticks remaining
+	life int
+
+	// text to show
+	txt string
+}
+
+// ticks a score pop-up is shown for
+const scorePopupTicks = 45
+
+// update particles, pop-ups & screen shake, called every tick
+func (g *Game) UpdateEffects() {
+
+	// particles
+	alive := g.particles[:0]
+	for _, p := range g.particles {
+		p.life--
+		if p.life <= 0 {
+			continue
+		}
+		p.x += p.vx
+		p.y += p.vy
+		p.vy += p.gravity
+		alive = append(alive, p)
+	}
+	g.particles = alive
+
+	// score pop-ups
+	popups := g.scorePopups[:0]
+	for _, p := range g.scorePopups {
+		p.life--
+		if p.life <= 0 {
+			continue
+		}
+		p.y -= 0.5
+		popups = append(popups, p)
+	}
+	g.scorePopups = popups
+
+	// screen shake
+	if g.shakeTicks > 0 {
+		g.shakeTicks--
+	}
+}
+
+// remove all effects
+func (g *Game) ClearEffects() {
+	g.particles = g.particles[:0]
+	g.scorePopups = g.scorePopups[:0]
+	g.shakeTicks = 0
+}
+
+// emit n particles from the centre of board tile x, y
+func (g *Game) EmitParticles(x, y, n int, colours []color.RGBA, speed, gravity float64, life int) {
+	if !g.settings.Particles {
+		return
+	}
+	cx := float64(x*TILESIZE) + TILESIZE/2
+	cy := float64(y*TILESIZE) + TILESIZE/2
+	for i := 0; i < n; i++ {
+		angle := rand.Float64() * 2 * math.Pi
+		v := speed * (0.5 + rand.Float64()/2)
+		l := life/2 + rand.Intn(life/2+1)
+		g.particles = append(g.particles, Particle{
+			x:       cx,
+			y:       cy,
+			vx:      math.Cos(angle) * v,
+			vy:      math.Sin(angle) * v,
+			gravity: gravity,
+			size:    float64(1 + rand.Intn(2)),
+			life:    l,
+			maxLife: l,
+			colour:  g.PaletteColour(colours[rand.Intn(len(colours))]),
+		})
+	}
+}
+
+// crumbs when a cupcake is eaten at board tile x, y
+func (g *Game) EmitCrumbs(x, y int) {
+	g.EmitParticles(x, y, 16, crumbColours, 1.2, 0.05, 40)
+}
+
+// dust when the snake turns at board tile x, y
+func (g *Game) EmitDust(x, y int) {
+	g.EmitParticles(x, y, 6, dustColours, 0.4, 0, 20)
+}
+
+// bone fragments when a segment at board tile x, y turns to skeleton
+func (g *Game) EmitBones(x, y int) {
+	g.EmitParticles(x, y, 4, boneColours, 0.8, 0.08, 30)
+}
+
+// confetti bursting from every segment of the snake, for a perfect game
+func (g *Game) Celebrate() {
+	for i := 0; i < g.SnakeBody.length; i++ {
+		seg := g.SnakeBody.Segment(i)
+		g.EmitParticles(seg.x, seg.y, 2, confettiColours, 1.5, 0.05, 60)
+	}
+}
+
+// show a score pop-up floating up from board tile x, y
+func (g *Game) AddScorePopup(x, y, points int) {
+	if !g.settings.ScorePopups {
+		return
+	}
+	g.scorePopups = append(g.scorePopups, ScorePopup{
+		x:    float64(x*TILESIZE + TILESIZE/2),
+		y:    float64(y*TILESIZE) - 4,
+		life: scorePopupTicks,
+		txt:  fmt.Sprintf("+%d", points),
+	})
+}
+
+// start shaking the screen
+func (g *Game) StartShake(ticks int) {
+	if !g.settings.ScreenShake {
+		return
+	}
+	g.shakeTicks = ticks
+	g.shakeTicksMax = ticks
+}
+
+// return the screen shake offset for this frame
+func (g *Game) ShakeOffset() (x, y float64) {
+	if g.shakeTicks <= 0 {
+		return 0, 0
+	}
+	// shake magnitude eases off as the shake ends
+	m := 4 * float64(g.shakeTicks) / float64(g.shakeTicksMax)
+	return (rand.Float64()*2 - 1) * m, (rand.Float64()*2 - 1) * m
+}
+
+// fade colour c (premultiplied) by alpha (0 to 1)
+func fadeColour(c color.RGBA, alpha float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(c.R) * alpha),
+		G: uint8(float64(c.G) * alpha),
+		B: uint8(float64(c.B) * alpha),
+		A: uint8(float64(c.A) * alpha),
+	}
+}
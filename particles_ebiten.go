@@ -0,0 +1,25 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// draw particles & score pop-ups, offsetting by yOffset (for score bar)
+func (g *Game) DrawEffects(imgOut *ebiten.Image, yOffset int) {
+	for _, p := range g.particles {
+		c := fadeColour(p.colour, float64(p.life)/float64(p.maxLife))
+		vector.DrawFilledRect(imgOut, float32(p.x), float32(p.y)+float32(yOffset), float32(p.size), float32(p.size), c, false)
+	}
+	for _, p := range g.scorePopups {
+		alpha := float64(p.life) / scorePopupTicks
+		g.DrawText(imgOut, p.txt, p.x, p.y+float64(yOffset), TextStyle{
+			Align:   text.AlignCenter,
+			Colour:  fadeColour(g.PaletteColour(textHighlightColour), alpha),
+			Outline: fadeColour(g.PaletteColour(textOutlineColour), alpha),
+		})
+	}
+}
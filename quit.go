@@ -0,0 +1,90 @@
+//go:build !headless
+
+package main
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// handle Q & the window's close button
+// returns ebiten.Termination once the game should end
+func (g *Game) UpdateQuit() error {
+
+	// closing the window can't wait for an answer
+	if ebiten.IsWindowBeingClosed() {
+		return g.Quit()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyQ) {
+		return g.RequestQuit()
+	}
+	return nil
+}
+
+// quit, asking first if a game is being played (so a stray Q doesn't end it)
+// from the countdown to the end of the death sequence; the countdown & the sequence wait for the answer
+func (g *Game) RequestQuit() error {
+	switch g.state {
+	case StateGameStart, StateInGame, StatePaused, StateGameEnd:
+		g.quitFrom = g.state
+		g.ChangeState(StateQuitConfirm)
+	case StateQuitConfirm:
+	default:
+		return g.Quit()
+	}
+	return nil
+}
+
+// update function for when asking whether to quit: Y or enter quits, N or ESC carries on
+func (g *Game) UpdateQuitConfirm() error {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyY), inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		return g.Quit()
+	case inpututil.IsKeyJustPressed(ebiten.KeyN), inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.CancelQuit()
+	}
+	return nil
+}
+
+// carry on with the game, rather than quitting
+func (g *Game) CancelQuit() {
+	// not ChangeState, which would start a new replay
+	g.state = g.quitFrom
+}
+
+// save everything not yet saved (the game in progress, settings, achievement stats & captures), and end the game
+// returns ebiten.Termination, which ebiten.RunGame returns as nil, so the program exits with status 0
+func (g *Game) Quit() error {
+	g.SaveGame()
+
+	g.CheckWindow()
+	err := g.settings.Save()
+	if err != nil {
+		log.Printf("could not save settings: %v", err)
+	}
+
+	if g.achievements != nil {
+		err = g.achievements.Save()
+		if err != nil {
+			log.Printf("could not save achievements: %v", err)
+		}
+	}
+
+	g.capture.Wait()
+	return ebiten.Termination
+}
+
+// draw the quit confirmation
+func (g *Game) DrawQuitConfirm(imgOut *ebiten.Image) {
+	_, h := g.ScreenSize()
+	mid := float64(h) / 2
+	g.DrawTextCentred(imgOut, g.T("quit.title"), mid-40, TextStyle{Colour: textHighlightColour, Outline: textOutlineColour})
+	keys := [][2]string{
+		{g.T("key.y"), g.T("quit.yes")},
+		{g.T("key.n"), g.T("quit.no")},
+	}
+	g.DrawKeyHelp(imgOut, mid-12, keys, TextStyle{Outline: textOutlineColour})
+}
@@ -0,0 +1,38 @@
+//go:build !headless
+
+package main
+
+import (
+	"testing"
+)
+
+// Q in game (countdown & death sequence too) asks before quitting, and saying no carries on with the game as it was
+func TestQuitConfirm(t *testing.T) {
+	h := NewHarness(t, 10, 8, 2, Rules{})
+	h.Play("UUL")
+
+	for _, from := range []gameState{StateGameStart, StateInGame, StatePaused, StateGameEnd} {
+		h.g.state = from
+		board := h.g.Board().String()
+		err := h.g.RequestQuit()
+		if err != nil {
+			t.Fatalf("quitting from state %d: %v, want confirmation first", from, err)
+		}
+		h.AssertState(StateQuitConfirm)
+
+		// asking again (Q pressed again) changes nothing
+		err = h.g.RequestQuit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.AssertState(StateQuitConfirm)
+
+		h.g.CancelQuit()
+		h.AssertState(from)
+		h.AssertBoard(board)
+	}
+
+	h.g.state = StateInGame
+	h.Play("D")
+	h.AssertState(StateInGame)
+}
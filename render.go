@@ -0,0 +1,362 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// software renderer: draws game frames into plain images on the CPU, without a window or GPU,
+// matching the look of DrawFood, DrawSnake & DrawScoreBar
+type SoftRenderer struct {
+
+	// the game being drawn
+	g *Game
+
+	// atlas image, remapped through the game's palette
+	atlas *image.RGBA
+
+	// sprite regions by name
+	regions map[string]image.Rectangle
+
+	// sprites by name & rotation, rotated as first needed
+	tiles map[softTile]*image.RGBA
+
+	// score bar font (primary font only)
+	face font.Face
+}
+
+// a sprite at a given rotation
+type softTile struct {
+
+	// atlas region name
+	name string
+
+	// clockwise quarter turns (0 to 3)
+	turns int
+
+	// mirrored left to right (before turning)?
+	mirrored bool
+}
+
+// return a new software renderer for game g, using g's palette & settings
+func NewSoftRenderer(g *Game) (*SoftRenderer, error) {
+	a, err := DecodeAtlas(pngAtlas, jsonAtlas)
+	if err != nil {
+		return nil, err
+	}
+	f, err := opentype.Parse(ttfPressStart2P)
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    FONTSIZE,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SoftRenderer{
+		g:       g,
+		atlas:   a.RemapSource(g.PaletteColour),
+		regions: a.regions,
+		tiles:   make(map[softTile]*image.RGBA),
+		face:    face,
+	}, nil
+}
+
+// draw the current frame of the game: score bar, food & snake
+func (r *SoftRenderer) Frame() *image.RGBA {
+	g := r.g
+	img := image.NewRGBA(image.Rect(0, 0, g.width*TILESIZE, g.height*TILESIZE+TILESIZE))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	r.DrawWalls(img, 15)
+	r.DrawFood(img, 15)
+	r.DrawSnake(g.SnakeBody, img, 15, g.MovementProgress())
+	r.DrawScoreBar(img)
+	return img
+}
+
+// return the named sprite, mirrored left to right if mirrored is true, then rotated clockwise by turns quarter turns
+func (r *SoftRenderer) Tile(name string, turns int, mirrored bool) *image.RGBA {
+	key := softTile{name, turns, mirrored}
+	if t, ok := r.tiles[key]; ok {
+		return t
+	}
+
+	// rotate square sprites around their centre
+	src := r.regions[name]
+	n := src.Dx()
+	t := image.NewRGBA(image.Rect(0, 0, n, n))
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			sx, sy := x, y
+			switch turns {
+			case 1:
+				sx, sy = y, n-1-x
+			case 2:
+				sx, sy = n-1-x, n-1-y
+			case 3:
+				sx, sy = n-1-y, x
+			}
+			if mirrored {
+				sx = n - 1 - sx
+			}
+			t.SetRGBA(x, y, r.atlas.RGBAAt(src.Min.X+sx, src.Min.Y+sy))
+		}
+	}
+	r.tiles[key] = t
+	return t
+}
+
+// draw tile t at pixel position x, y, with an outline if outline is true (and enabled in settings)
+func (r *SoftRenderer) DrawTile(imgOut *image.RGBA, t *image.RGBA, x, y int, outline bool) {
+	rect := t.Bounds().Add(image.Pt(x, y))
+	if outline && r.g.settings.Outline {
+		c := image.NewUniform(r.g.Palette().outline)
+		for _, d := range []image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			draw.DrawMask(imgOut, rect.Add(d), c, image.Point{}, t, image.Point{}, draw.Over)
+		}
+	}
+	draw.Draw(imgOut, rect, t, image.Point{}, draw.Over)
+}
+
+// draw the walls around the edge of the board (if the rules have walls), offsetting by yOffset (for score bar)
+func (r *SoftRenderer) DrawWalls(imgOut *image.RGBA, yOffset int) {
+	g := r.g
+	walls, flips := g.EdgeRects(yOffset)
+	for _, edge := range walls {
+		draw.Draw(imgOut, edge, image.NewUniform(g.PaletteColour(colourCase)), image.Point{}, draw.Src)
+	}
+	for _, edge := range flips {
+		draw.Draw(imgOut, edge, image.NewUniform(g.PaletteColour(colourIcing)), image.Point{}, draw.Src)
+	}
+}
+
+// draw the food tile, offsetting by yOffset (for score bar)
+func (r *SoftRenderer) DrawFood(imgOut *image.RGBA, yOffset int) {
+	f := r.g.food
+	r.DrawTile(imgOut, r.Tile("cupcake", 0, false), f.x*TILESIZE, f.y*TILESIZE+yOffset, true)
+}
+
+// draw the snake, offsetting by yOffset (for score bar)
+// progress is how far the snake is from the cells it has just left (0) to its cells (1), used to interpolate the head & tail
+func (r *SoftRenderer) DrawSnake(SnakeBody *SnakeBody, imgOut *image.RGBA, yOffset int, progress float64) {
+	for i := SnakeBody.length - 1; i >= 0; i-- {
+		seg := SnakeBody.Segment(i)
+
+		// tile sprite, from the segments either side (as in DrawSnake, tail first)
+		tile := SnakeBody.Tile(i)
+
+		// get pixel position for segment, sliding the head & tail as in DrawSnake
+		xpos := float64(seg.x * TILESIZE)
+		ypos := float64(seg.y * TILESIZE)
+		if i == 0 || i == SnakeBody.length-1 && !SnakeBody.grew {
+			dx, dy := DirectionDelta(seg.facing)
+			xpos -= float64(dx*TILESIZE) * (1 - progress)
+			ypos -= float64(dy*TILESIZE) * (1 - progress)
+		}
+
+		// draw tile (twice if it is part way across the edge of the board)
+		for _, pos := range r.g.WrappedPositions(xpos, ypos) {
+			t := r.Tile(tile.AtlasName(seg.skeleton), tile.Turns, tile.Mirrored != pos.flipped)
+			r.DrawTile(imgOut, t, int(math.Round(pos.x)), int(math.Round(pos.y))+yOffset, i == 0 && !seg.skeleton)
+		}
+	}
+}
+
+// draw the score bar at the top of the frame
+func (r *SoftRenderer) DrawScoreBar(imgOut *image.RGBA) {
+	g := r.g
+	bar := image.Rect(0, 0, imgOut.Bounds().Dx(), TILESIZE)
+	draw.Draw(imgOut, bar, image.NewUniform(g.PaletteColour(colourDark)), image.Point{}, draw.Src)
+
+	// centred text, vertically centred like DrawScoreBar
+	txt := g.T("game.calories", g.scoring.Breakdown.Total())
+	d := font.Drawer{
+		Dst:  imgOut,
+		Src:  image.NewUniform(g.PaletteColour(textColour)),
+		Face: r.face,
+	}
+	width := d.MeasureString(txt)
+	top := fixed.I((TILESIZE - FONTSIZE) / 2)
+	d.Dot = fixed.Point26_6{
+		X: (fixed.I(bar.Dx()) - width) / 2,
+		Y: top + r.face.Metrics().Ascent,
+	}
+	d.DrawString(txt)
+}
+
+// return img scaled up by a whole number, keeping pixels crisp
+func ScaleImage(img *image.RGBA, scale int) *image.RGBA {
+	if scale <= 1 {
+		return img
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx()*scale, b.Dy()*scale))
+	for y := 0; y < out.Rect.Dy(); y++ {
+		for x := 0; x < out.Rect.Dx(); x++ {
+			out.SetRGBA(x, y, img.RGBAAt(b.Min.X+x/scale, b.Min.Y+y/scale))
+		}
+	}
+	return out
+}
+
+// "render" command: play back a replay without a window, writing every frame as a PNG sequence, an animated GIF or an animated PNG
+func RenderCommand(args []string) error {
+	defaults := DefaultSettings()
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	out := fs.String("out", "", "output: a directory for a PNG sequence, or a file ending .gif for an animated GIF or .png for an animated PNG (default: replay name)")
+	every := fs.Int("every", 0, "render every nth tick (default: 1 for a PNG sequence, 3 for an animation)")
+	scale := fs.Int("scale", 1, "scale frames up by this whole number")
+	smooth := fs.Bool("smooth", defaults.SmoothMovement, "smooth movement")
+	palette := fs.String("palette", defaults.Palette, "colour palette")
+	outline := fs.Bool("outline", defaults.Outline, "outline head & food")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: snake render [flags] replay.json")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("render: expected one replay file")
+	}
+
+	// load replay & set up the game to play it back
+	replay, err := LoadReplay(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	g, err := NewHeadlessGame(replay.Width, replay.Height)
+	if err != nil {
+		return err
+	}
+	g.settings.SmoothMovement = *smooth
+	g.settings.Palette = *palette
+	g.settings.Outline = *outline
+	p, err := NewReplayPlayer(g, replay)
+	if err != nil {
+		return err
+	}
+	r, err := NewSoftRenderer(g)
+	if err != nil {
+		return err
+	}
+
+	// output
+	name := *out
+	if name == "" {
+		name = strings.TrimSuffix(fs.Arg(0), filepath.Ext(fs.Arg(0)))
+	}
+	isGIF := strings.EqualFold(filepath.Ext(name), ".gif")
+	isAPNG := strings.EqualFold(filepath.Ext(name), ".png")
+	if *every <= 0 {
+		*every = 1
+		if isGIF || isAPNG {
+			*every = captureEveryTicks
+		}
+	}
+	if !isGIF && !isAPNG {
+		err = os.MkdirAll(name, 0o755)
+		if err != nil {
+			return err
+		}
+	}
+
+	// play back, rendering every nth tick (including the first & last)
+	// animation frames are converted to paletted images (GIF) or compressed (APNG) as they are rendered,
+	// to keep memory down on long games
+	var anim gif.GIF
+	apng := NewAPNG(*every, ticksPerSecond)
+	frames := 0
+	render := func() error {
+		img := ScaleImage(r.Frame(), *scale)
+		if isGIF {
+			f := EncodeGIF([]*image.RGBA{img}, max(2, *every*100/ticksPerSecond))
+			anim.Image = append(anim.Image, f.Image...)
+			anim.Delay = append(anim.Delay, f.Delay...)
+		} else if isAPNG {
+			err := apng.Add(img)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := WritePNG(filepath.Join(name, fmt.Sprintf("frame-%05d.png", frames)), img)
+			if err != nil {
+				return err
+			}
+		}
+		frames++
+		return nil
+	}
+	for {
+		if p.tick%*every == 0 {
+			err = render()
+			if err != nil {
+				return err
+			}
+		}
+		if !p.Step() {
+			break
+		}
+	}
+	if p.tick%*every != 0 {
+		err = render()
+		if err != nil {
+			return err
+		}
+	}
+
+	if isGIF || isAPNG {
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		if isGIF {
+			err = gif.EncodeAll(f, &anim)
+		} else {
+			err = apng.Encode(f)
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+		err = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("rendered %d frames (%d ticks) to %s\n", frames, p.tick, name)
+	return nil
+}
+
+// write img to file name as a PNG
+func WritePNG(name string, img image.Image) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
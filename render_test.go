@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"image/png"
+	"testing"
+)
+
+// frames rendered by the software renderer match the golden frames in testdata/golden
+// (run go test -update to rewrite them after changing how the game looks)
+func TestGoldenFrames(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		rules  Rules
+		smooth bool
+		moves  string
+
+		// ticks to play after the moves (to catch the snake part way between cells, or as a skeleton)
+		// with none, the frame is of the tick the snake last moved on
+		ticks int
+	}{
+		{"start", Rules{}, false, "", 0},
+		{"wrap_bend", Rules{}, false, "UUUUUL", 0},
+		{"wrap_smooth", Rules{}, true, "UUUUULLLLLLD", 20},
+		{"smooth_after_move", Rules{}, true, "UUUUUL", 0},
+		{"walls_skeleton", Rules{Walls: true}, false, "UUUUU", 100},
+		{"klein_flip", Rules{Topology: "klein"}, false, "UUUUULLD", 0},
+		{"projective_flip", Rules{Topology: "projective"}, true, "LLLLLLDD", 20},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h := NewHarness(t, 10, 8, 1, test.rules)
+			h.g.settings.SmoothMovement = test.smooth
+			h.Play(test.moves)
+			h.RunTo(h.tick + test.ticks)
+			h.AssertFrame(test.name)
+		})
+	}
+}
+
+// every frame of an animated PNG decodes back to the frame rendered
+func TestAPNGFrames(t *testing.T) {
+	h := NewHarness(t, 10, 8, 1, Rules{})
+	r, err := NewSoftRenderer(h.g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewAPNG(3, ticksPerSecond)
+	var want []*image.RGBA
+	for _, d := range "UULL" {
+		h.Play(string(d))
+		img := r.Frame()
+		want = append(want, img)
+		err = a.Add(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var b bytes.Buffer
+	err = a.Encode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// viewers without APNG support see the first frame
+	img, err := png.Decode(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSameImage(t, 0, img, want[0])
+
+	// chunks: the frame count, then numbered frames, each decoding (as a PNG of its own) to the frame rendered
+	var header []byte
+	var frames [][]byte
+	seq := uint32(0)
+	rd := bytes.NewReader(b.Bytes()[len(pngSignature):])
+	for {
+		typ, data, err := readPNGChunk(rd)
+		if err != nil {
+			break
+		}
+		switch typ {
+		case "IHDR":
+			header = data
+		case "acTL":
+			if n := binary.BigEndian.Uint32(data); n != uint32(len(want)) {
+				t.Fatalf("acTL: %d frames, want %d", n, len(want))
+			}
+		case "fcTL", "fdAT":
+			if n := binary.BigEndian.Uint32(data); n != seq {
+				t.Fatalf("%s: sequence number %d, want %d", typ, n, seq)
+			}
+			seq++
+			if typ == "fdAT" {
+				frames = append(frames, data[4:])
+			}
+		case "IDAT":
+			frames = append(frames, data)
+		}
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("%d frames, want %d", len(frames), len(want))
+	}
+	for i, data := range frames {
+		var f bytes.Buffer
+		f.Write(pngSignature)
+		writePNGChunk(&f, "IHDR", header)
+		writePNGChunk(&f, "IDAT", data)
+		writePNGChunk(&f, "IEND", nil)
+		img, err := png.Decode(&f)
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		assertSameImage(t, i, img, want[i])
+	}
+}
+
+// fail unless frame i, img, is the same as want
+func assertSameImage(t *testing.T, i int, img image.Image, want *image.RGBA) {
+	t.Helper()
+	got := image.NewRGBA(img.Bounds())
+	draw.Draw(got, got.Rect, img, img.Bounds().Min, draw.Src)
+	if got.Rect != want.Rect || !bytes.Equal(got.Pix, want.Pix) {
+		t.Fatalf("frame %d differs from the frame rendered", i)
+	}
+}
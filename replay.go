@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// replay file format version, increased whenever the format or the game rules change
+const replayVersion = 4
+
+// characters used to record each direction in a replay, indexed by direction-1
+const replayMoveChars = "UDLR"
+
+// a recorded game: everything needed to play it back exactly
+type Replay struct {
+
+	// replay file format version
+	Version int `json:"version"`
+
+	// when the game was played
+	Date time.Time `json:"date"`
+
+	// random number generator seed (food placement)
+	Seed uint64 `json:"seed"`
+
+	// size of game board
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// rules the game was played with
+	Rules
+
+	// direction of every movement step, one character (U, D, L or R) per step
+	Moves string `json:"moves"`
+
+	// final score (cupcakes eaten)
+	Score int `json:"score"`
+
+	// final points (calories), and where they came from
+	Points    int            `json:"points,omitempty"`
+	Breakdown ScoreBreakdown `json:"breakdown"`
+}
+
+// return a new, empty replay for a game with the given seed, board size & rules
+func NewReplay(seed uint64, width, height int, rules Rules) *Replay {
+	return &Replay{
+		Version: replayVersion,
+		Date:    time.Now(),
+		Seed:    seed,
+		Width:   width,
+		Height:  height,
+		Rules:   rules,
+	}
+}
+
+// record a movement step in direction d
+func (r *Replay) Record(d snakeDirection) {
+	r.Moves += replayMoveChars[d-1 : d]
+}
+
+// return the direction of movement step i
+func (r *Replay) Move(i int) snakeDirection {
+	return snakeDirection(strings.IndexByte(replayMoveChars, r.Moves[i]) + 1)
+}
+
+// load a replay from file name
+func LoadReplay(name string) (*Replay, error) {
+	var r Replay
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	// check the replay can be played back
+	if r.Version != replayVersion {
+		return nil, fmt.Errorf("%s: replay version %d not supported (expected %d)", name, r.Version, replayVersion)
+	}
+	if r.Width < 5 || r.Height < 5 {
+		return nil, fmt.Errorf("%s: board size %dx%d too small", name, r.Width, r.Height)
+	}
+	if i := strings.IndexFunc(r.Moves, func(c rune) bool { return !strings.ContainsRune(replayMoveChars, c) }); i >= 0 {
+		return nil, fmt.Errorf("%s: invalid move %q at step %d", name, r.Moves[i], i)
+	}
+	return &r, nil
+}
+
+// save the replay to file name
+func (r *Replay) Save(name string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, b, 0o644)
+}
+
+// return the directory replays are saved to, creating it if needed
+func ReplayDir() (string, error) {
+	dir, err := DataPath("replays")
+	if err != nil {
+		return "", err
+	}
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// save the replay of the game just finished to the replays directory
+func (g *Game) SaveReplay() {
+	r := g.replay
+	r.Score = g.score
+	r.Breakdown = g.scoring.Breakdown
+	r.Points = r.Breakdown.Total()
+	g.UpdateBestReplay(r)
+	if g.daily != nil {
+		g.RecordDailyResult(r)
+	}
+	dir, err := ReplayDir()
+	if err == nil {
+		err = r.Save(filepath.Join(dir, fmt.Sprintf("snake-%s.json", r.Date.Format("20060102-150405"))))
+	}
+	if err != nil {
+		log.Printf("could not save replay: %v", err)
+	}
+}
+
+// take a snapshot of the game being played back every this many ticks, for fast seeking
+const replaySnapshotTicks = 300
+
+// a game being played back from a replay
+type ReplayPlayer struct {
+
+	// the game being played back (not the game shown in the window)
+	g *Game
+
+	// the replay being played back
+	replay *Replay
+
+	// index of the next movement step
+	move int
+
+	// ticks played back so far
+	tick int
+
+	// length of the replay in ticks
+	length int
+
+	// snapshots of the game every replaySnapshotTicks ticks
+	snapshots []replaySnapshot
+
+	// ticks at which the snake moved, and at which it ate a cupcake
+	moveTicks []int
+	eatTicks  []int
+}
+
+// the state of a game being played back at a given tick
+type replaySnapshot struct {
+
+	// position in the replay
+	tick int
+	move int
+
+	// game state
+	state            gameState
+	snake            *SnakeBody
+	direction        snakeDirection
+	food             Food
+	score            int
+	scoring          Scoring
+	ticks            int
+	ticksPerMovement int
+	skeleTicks       int
+}
+
+// return a new game for playing back replays without a window, with a board of width x height
+func NewHeadlessGame(width, height int) (*Game, error) {
+	var err error
+	g := Game{
+		width:                width,
+		height:               height,
+		defaultWidth:         width,
+		defaultHeight:        height,
+		skeleTicksPerSegment: 2,
+		tongueTicksMin:       20,
+	}
+	g.catalogues, err = LoadCatalogues()
+	if err != nil {
+		return nil, err
+	}
+	g.settings.Language = defaultLanguage
+	return &g, nil
+}
+
+// return a new player for replay r, playing back on game g (which must have the replay's board size)
+// the replay is played through once to find its length & take snapshots, then rewound to the start
+func NewReplayPlayer(g *Game, r *Replay) (*ReplayPlayer, error) {
+	if g.width != r.Width || g.height != r.Height {
+		return nil, fmt.Errorf("replay board size %dx%d does not match %dx%d", r.Width, r.Height, g.width, g.height)
+	}
+	p := ReplayPlayer{g: g, replay: r}
+	g.rules = r.Rules
+	g.Reset(r.Seed)
+	g.ChangeState(StateInGame)
+	for {
+		if p.tick%replaySnapshotTicks == 0 {
+			p.snapshots = append(p.snapshots, p.Snapshot())
+		}
+		move, score := p.move, g.score
+		if !p.Step() {
+			break
+		}
+		if p.move != move {
+			p.moveTicks = append(p.moveTicks, p.tick)
+		}
+		if g.score != score {
+			p.eatTicks = append(p.eatTicks, p.tick)
+		}
+	}
+	p.length = p.tick
+	p.Seek(0)
+	return &p, nil
+}
+
+// play back one tick of the replay
+// returns false once the game is over (the snake has turned to skeleton), or the replay has run out of moves
+func (p *ReplayPlayer) Step() bool {
+	g := p.g
+	switch g.state {
+	case StateInGame:
+		if g.MoveDue() {
+			if p.move >= len(p.replay.Moves) {
+				return false
+			}
+			g.SnakeDirection = p.replay.Move(p.move)
+			p.move++
+		}
+		g.StepInGame()
+	case StateGameEnd:
+		g.UpdateEndGame()
+	default:
+		return false
+	}
+	p.tick++
+	return true
+}
+
+// take a snapshot of the game at the current tick
+func (p *ReplayPlayer) Snapshot() replaySnapshot {
+	g := p.g
+	return replaySnapshot{
+		tick:             p.tick,
+		move:             p.move,
+		state:            g.state,
+		snake:            g.SnakeBody.Clone(),
+		direction:        g.SnakeDirection,
+		food:             *g.food,
+		score:            g.score,
+		scoring:          g.scoring,
+		ticks:            g.ticks,
+		ticksPerMovement: g.ticksPerMovement,
+		skeleTicks:       g.skeleTicks,
+	}
+}
+
+// restore the game to snapshot s
+func (p *ReplayPlayer) Restore(s replaySnapshot) {
+	g := p.g
+	p.tick = s.tick
+	p.move = s.move
+	g.state = s.state
+	g.SnakeBody = s.snake.Clone()
+	g.occupancy = NewOccupancy(g.width, g.height)
+	g.occupancy.AddSnake(g.SnakeBody)
+	g.SnakeDirection = s.direction
+	food := s.food
+	g.food = &food
+	g.score = s.score
+	g.scoring = s.scoring
+	g.ticks = s.ticks
+	g.ticksPerMovement = s.ticksPerMovement
+	g.skeleTicks = s.skeleTicks
+	g.ClearEffects()
+}
+
+// jump to tick (clamped to the length of the replay), from the nearest snapshot before it
+func (p *ReplayPlayer) Seek(tick int) {
+	tick = max(0, min(tick, p.length))
+	p.Restore(p.snapshots[min(tick/replaySnapshotTicks, len(p.snapshots)-1)])
+	for p.tick < tick && p.Step() {
+	}
+}
+
+// jump to the next movement step after the current tick (or the end)
+func (p *ReplayPlayer) StepForward() {
+	i := sort.SearchInts(p.moveTicks, p.tick+1)
+	if i < len(p.moveTicks) {
+		p.Seek(p.moveTicks[i])
+	} else {
+		p.Seek(p.length)
+	}
+}
+
+// jump to the movement step before the current tick (or the start)
+func (p *ReplayPlayer) StepBack() {
+	i := sort.SearchInts(p.moveTicks, p.tick)
+	if i > 0 {
+		p.Seek(p.moveTicks[i-1])
+	} else {
+		p.Seek(0)
+	}
+}
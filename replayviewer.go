@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+)
+
+// playback speeds offered by the replay viewer
+var replaySpeeds = []float64{0.25, 0.5, 1, 2, 4, 8}
+
+// index of normal speed in replaySpeeds
+const replayNormalSpeed = 2
+
+// number of replays shown at once on the replay list
+const replayListRows = 10
+
+// ticks skipped by page up/down in the replay viewer
+const replaySkipTicks = 600
+
+// a saved replay on the replay list
+type ReplayFile struct {
+
+	// path of the replay file
+	path string
+
+	// the replay
+	replay *Replay
+}
+
+// replay viewer state
+type ReplayViewer struct {
+
+	// the replay being watched (playing back on its own game)
+	player *ReplayPlayer
+
+	// is playback paused?
+	paused bool
+
+	// index into replaySpeeds
+	speed int
+
+	// fraction of a tick carried over between updates (for speeds below 1x)
+	ticks float64
+
+	// is the timeline being dragged with the mouse?
+	dragging bool
+}
+
+// load the list of saved replays, newest first
+// replays that can't be loaded are skipped
+func LoadReplayFiles() ([]ReplayFile, error) {
+	dir, err := ReplayDir()
+	if err != nil {
+		return nil, err
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var files []ReplayFile
+	for _, name := range names {
+		r, err := LoadReplay(name)
+		if err != nil {
+			log.Printf("skipping replay: %v", err)
+			continue
+		}
+		files = append(files, ReplayFile{path: name, replay: r})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].replay.Date.After(files[j].replay.Date)
+	})
+	return files, nil
+}
+
+// start watching replay r
+func (g *Game) WatchReplay(r *Replay) error {
+
+	// the replay plays back on a copy of the game, with the replay's board size
+	g.SetBoardSize(r.Width, r.Height)
+	p, err := NewReplayPlayer(g.PlaybackGame(), r)
+	if err != nil {
+		g.SetBoardSize(g.defaultWidth, g.defaultHeight)
+		return err
+	}
+	g.viewer = &ReplayViewer{
+		player: p,
+		speed:  replayNormalSpeed,
+	}
+	g.ChangeState(StateReplayView)
+	return nil
+}
+
+// open the replay list, loading the saved replays
+func (g *Game) OpenReplayList() {
+	var err error
+	g.replays, err = LoadReplayFiles()
+	if err != nil {
+		log.Printf("could not load replays: %v", err)
+	}
+	g.replayCursor = min(g.replayCursor, max(len(g.replays)-1, 0))
+}
+
+// format a number of ticks as minutes & seconds
+func ReplayTime(ticks int) string {
+	s := ticks / ticksPerSecond
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
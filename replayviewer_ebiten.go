@@ -0,0 +1,192 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// update function for when in the replay list
+func (g *Game) UpdateReplayList() error {
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.ChangeState(StateMainMenu)
+	case len(g.replays) == 0:
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		g.replayCursor = (g.replayCursor + len(g.replays) - 1) % len(g.replays)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		g.replayCursor = (g.replayCursor + 1) % len(g.replays)
+	case inpututil.IsKeyJustPressed(ebiten.KeySpace), inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		err := g.WatchReplay(g.replays[g.replayCursor].replay)
+		if err != nil {
+			log.Printf("could not watch replay %s: %v", g.replays[g.replayCursor].path, err)
+		}
+	}
+	return nil
+}
+
+// update function for when watching a replay
+func (g *Game) UpdateReplayView() error {
+	v := g.viewer
+	p := v.player
+
+	// handle input
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.ChangeState(StateReplayList)
+		return nil
+	case inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		// play from the start again if the end has been reached
+		v.paused = !v.paused
+		if !v.paused && p.tick >= p.length {
+			p.Seek(0)
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		v.speed = min(v.speed+1, len(replaySpeeds)-1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		v.speed = max(v.speed-1, 0)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowRight):
+		v.paused = true
+		p.StepForward()
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft):
+		v.paused = true
+		p.StepBack()
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageUp):
+		p.Seek(p.tick - replaySkipTicks)
+	case inpututil.IsKeyJustPressed(ebiten.KeyPageDown):
+		p.Seek(p.tick + replaySkipTicks)
+	}
+
+	// click or drag on the timeline to jump to any tick
+	bar := g.ReplayTimelineRect()
+	x, y := g.CursorPosition()
+	switch {
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		v.dragging = image.Pt(x, y).In(bar.Inset(-4))
+	case !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft):
+		v.dragging = false
+	}
+	if v.dragging {
+		tick := (x - bar.Min.X) * p.length / bar.Dx()
+		if tick != p.tick {
+			p.Seek(tick)
+		}
+		p.g.UpdateCamera()
+		return nil
+	}
+
+	// play
+	if !v.paused {
+		v.ticks += replaySpeeds[v.speed]
+		for v.ticks >= 1 {
+			v.ticks--
+			if !p.Step() {
+				v.paused = true
+				v.ticks = 0
+				break
+			}
+			p.g.UpdateEffects()
+			p.g.RandomSnakeTongue()
+		}
+	}
+
+	// camera follows the snake
+	p.g.UpdateCamera()
+	return nil
+}
+
+// draw the replay list
+func (g *Game) DrawReplayList(imgOut *ebiten.Image) {
+	w, _ := g.ScreenSize()
+	left := float64(w)/2 - 150
+	right := float64(w)/2 + 150
+	g.DrawTextCentred(imgOut, g.T("replays.title"), 40, TextStyle{Colour: textHighlightColour, Outline: textOutlineColour})
+	if len(g.replays) == 0 {
+		g.DrawTextCentred(imgOut, g.T("replays.none"), 80, TextStyle{Outline: textOutlineColour})
+	}
+
+	// scroll so the cursor is always shown
+	first := max(0, min(g.replayCursor-replayListRows/2, len(g.replays)-replayListRows))
+	for i := first; i < min(first+replayListRows, len(g.replays)); i++ {
+		r := g.replays[i].replay
+		y := float64(80 + (i-first)*15)
+		style := TextStyle{Outline: textOutlineColour}
+		if i == g.replayCursor {
+			style.Colour = textHighlightColour
+			g.DrawText(imgOut, ">", left-2*FONTSIZE, y, style)
+		}
+		g.DrawText(imgOut, r.Date.Local().Format("2006-01-02 15:04"), left, y, style)
+		style.Align = text.AlignEnd
+		g.DrawText(imgOut, g.T("game.calories", r.Points), right, y, style)
+	}
+
+	g.DrawKeyHelp(imgOut, 240, [][2]string{
+		{g.T("key.updown"), g.T("settings.select")},
+		{g.T("key.space"), g.T("replays.watch")},
+		{g.T("key.esc"), g.T("settings.back")},
+	}, TextStyle{Outline: textOutlineColour})
+}
+
+// draw the replay being watched, with its timeline
+func (g *Game) DrawReplayView(imgOut *ebiten.Image) {
+	v := g.viewer
+	p := v.player
+	sim := p.g
+
+	// the game, as it was drawn when played
+	over := sim.state == StateGameOver
+	world := sim.World()
+	sim.DrawWalls(world, 0)
+	sim.DrawFood(world, 0, over)
+	sim.DrawSnake(sim.SnakeBody, world, 0, over, sim.MovementProgress())
+	sim.DrawEffects(world, 0)
+	sim.DrawView(imgOut, 15)
+	sim.DrawScoreBar(imgOut)
+
+	// timeline strip along the bottom: status, time, and a bar marking each cupcake eaten
+	w, h := g.ScreenSize()
+	bar := g.ReplayTimelineRect()
+	vector.DrawFilledRect(imgOut, 0, float32(h-24), float32(w), 24, fadeColour(g.PaletteColour(colourDark), 0.85), false)
+	status := g.T("replay.speed_value", replaySpeeds[v.speed])
+	if v.paused {
+		status = g.T("replay.paused")
+	}
+	style := TextStyle{Outline: textOutlineColour}
+	g.DrawText(imgOut, status, float64(bar.Min.X), float64(h-22), style)
+	style.Align = text.AlignEnd
+	g.DrawText(imgOut, fmt.Sprintf("%s / %s", ReplayTime(p.tick), ReplayTime(p.length)), float64(bar.Max.X), float64(h-22), style)
+
+	length := float32(max(p.length, 1))
+	x, y, bw, bh := float32(bar.Min.X), float32(bar.Min.Y), float32(bar.Dx()), float32(bar.Dy())
+	vector.DrawFilledRect(imgOut, x, y, bw, bh, g.PaletteColour(colourSnakeGreen), false)
+	vector.DrawFilledRect(imgOut, x, y, bw*float32(p.tick)/length, bh, g.PaletteColour(colourSnakeYellow), false)
+	for _, t := range p.eatTicks {
+		vector.DrawFilledRect(imgOut, x+bw*float32(t)/length-1, y-2, 2, bh+4, g.PaletteColour(colourIcing), false)
+	}
+	vector.DrawFilledRect(imgOut, x+bw*float32(p.tick)/length-1, y-3, 3, bh+6, g.PaletteColour(colourSnakeRed), false)
+
+	// key help while paused
+	if v.paused {
+		g.DrawKeyHelp(imgOut, 110, [][2]string{
+			{g.T("key.space"), g.T("replay.play_pause")},
+			{g.T("key.leftright"), g.T("replay.step")},
+			{g.T("key.updown"), g.T("replay.speed")},
+			{g.T("key.pageupdown"), g.T("replay.skip")},
+			{g.T("key.mouse"), g.T("replay.jump")},
+			{g.T("key.esc"), g.T("settings.back")},
+		}, TextStyle{Outline: textOutlineColour})
+	}
+}
+
+// return the rectangle of the replay viewer's timeline bar, in frame pixels
+func (g *Game) ReplayTimelineRect() image.Rectangle {
+	w, h := g.ScreenSize()
+	return image.Rect(8, h-8, w-8, h-4)
+}
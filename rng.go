@@ -0,0 +1,28 @@
+package main
+
+import (
+	"math/rand/v2"
+)
+
+// random number generator for game logic (eg: food placement)
+// seeded from the game's seed, so a game can be played back exactly from its seed & the player's moves
+type RNG struct {
+	rand *rand.Rand
+}
+
+// return a new random number generator for sequence number stream of seed
+func NewRNG(seed, stream uint64) *RNG {
+	return &RNG{
+		rand: rand.New(rand.NewPCG(seed, stream)),
+	}
+}
+
+// return a random number in [0, n)
+func (r *RNG) Intn(n int) int {
+	return r.rand.IntN(n)
+}
+
+// return a new random seed
+func NewSeed() uint64 {
+	return rand.Uint64()
+}
@@ -0,0 +1,57 @@
+package main
+
+// rules a game is played with, recorded in its replay
+// the zero value is the classic game: wrap around the edges at normal speed
+type Rules struct {
+
+	// moving off the edge of the board is death, rather than wrapping around
+	Walls bool `json:"walls,omitempty"`
+
+	// speed preset id ("" for normal)
+	Speed string `json:"speed,omitempty"`
+
+	// a snake's tail is solid until it has moved, so a head can't follow straight into the cell it leaves
+	TailBlocks bool `json:"tailBlocks,omitempty"`
+
+	// board topology id ("" for the torus: wrap around every edge)
+	Topology string `json:"topology,omitempty"`
+}
+
+// a speed preset: how many ticks between moves, at the start of the game and as the score increases
+type SpeedPreset struct {
+
+	// rules value for the preset
+	id string
+
+	// message key of the preset's name
+	name string
+
+	// ticks before the first move
+	first int
+
+	// ticks between moves, less one per cupcake eaten, down to fastest
+	base    int
+	fastest int
+}
+
+// available speed presets, slowest first
+var speedPresets = []SpeedPreset{
+	{"relaxed", "speed.relaxed", 40, 50, 12},
+	{"normal", "speed.normal", 30, 40, 7},
+	{"fast", "speed.fast", 20, 28, 4},
+}
+
+// return the current speed preset
+func (g *Game) Speed() SpeedPreset {
+	for _, s := range speedPresets {
+		if s.id == g.rules.Speed {
+			return s
+		}
+	}
+	return speedPresets[1]
+}
+
+// return every snake on the board, all of which snakes collide with
+func (g *Game) Snakes() []*SnakeBody {
+	return []*SnakeBody{g.SnakeBody}
+}
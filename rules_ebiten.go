@@ -0,0 +1,19 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// draw the walls at the edges of the board (and marks on edges that flip the board over), offsetting by yOffset (for score bar)
+func (g *Game) DrawWalls(imgOut *ebiten.Image, yOffset int) {
+	walls, flips := g.EdgeRects(yOffset)
+	for _, r := range walls {
+		vector.DrawFilledRect(imgOut, float32(r.Min.X), float32(r.Min.Y), float32(r.Dx()), float32(r.Dy()), g.PaletteColour(colourCase), false)
+	}
+	for _, r := range flips {
+		vector.DrawFilledRect(imgOut, float32(r.Min.X), float32(r.Min.Y), float32(r.Dx()), float32(r.Dy()), g.PaletteColour(colourIcing), false)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+)
+
+// name of the saved game file, in the data directory
+const savedGameFile = "savegame.json"
+
+// saved game file format version, increased whenever the format changes
+const savedGameVersion = 1
+
+// a game in progress, saved to be resumed from the main menu
+type SavedGame struct {
+
+	// saved game file format version
+	Version int `json:"version"`
+
+	// when the game was saved
+	Date time.Time `json:"date"`
+
+	// the board & the game's state, as text (see Board)
+	// food is placed from the seed & the score (see NewRNG), so this is the random number generator's state too
+	Board string `json:"board"`
+
+	// replay of the game so far
+	Replay *Replay `json:"replay"`
+
+	// racing the ghost: the replay it is replaying, and how far into it it is
+	Ghost     *Replay `json:"ghost,omitempty"`
+	GhostTick int     `json:"ghostTick,omitempty"`
+
+	// daily challenge being played (nil if not)
+	Daily *Daily `json:"daily,omitempty"`
+
+	// achievement stats for the game so far
+	Stats GameStats `json:"stats"`
+}
+
+// return the game in progress, to be saved
+func (g *Game) SavedGame() *SavedGame {
+	b := g.Board()
+	b.State = StateInGame
+	s := SavedGame{
+		Version: savedGameVersion,
+		Date:    time.Now(),
+		Board:   b.String(),
+		Replay:  g.replay,
+		Daily:   g.daily,
+		Stats:   g.stats,
+	}
+	if g.ghost != nil {
+		s.Ghost = g.ghost.replay
+		s.GhostTick = g.ghost.tick
+	}
+	return &s
+}
+
+// check a saved game (just loaded) can be resumed, returning its board
+func (s *SavedGame) Check() (Board, error) {
+	if s.Version != savedGameVersion {
+		return Board{}, fmt.Errorf("saved game version %d not supported (expected %d)", s.Version, savedGameVersion)
+	}
+	b, err := ParseBoard(s.Board)
+	if err != nil {
+		return b, err
+	}
+	if b.State != StateInGame {
+		return b, errors.New("saved game is not in progress")
+	}
+
+	// the replay must be for the board, and from this version of the game's rules, to be carried on
+	if s.Replay == nil || s.Replay.Version != replayVersion {
+		return b, errors.New("saved game's replay is missing, or from an older version of the game")
+	}
+	if s.Replay.Width != b.Width || s.Replay.Height != b.Height || s.Replay.Seed != b.Seed || s.Replay.Rules != b.Rules {
+		return b, errors.New("saved game's replay does not match its board")
+	}
+	if s.Ghost != nil && (s.Ghost.Width != b.Width || s.Ghost.Height != b.Height) {
+		return b, errors.New("saved game's ghost does not match its board")
+	}
+	return b, nil
+}
+
+// load the saved game from the data directory
+// if there is no saved game, nil is returned
+func LoadSavedGame() (*SavedGame, error) {
+	path, err := DataPath(savedGameFile)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s SavedGame
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	_, err = s.Check()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// save the saved game to the data directory
+func (s *SavedGame) Save() error {
+	path, err := DataPath(savedGameFile)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// remove the saved game from the data directory (once it has been resumed)
+func RemoveSavedGame() error {
+	path, err := DataPath(savedGameFile)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// save the game in progress (if there is one) to the data directory, to be resumed later
+func (g *Game) SaveGame() {
+	state := g.state
+	if state == StateQuitConfirm {
+		state = g.quitFrom
+	}
+	switch state {
+	case StateInGame, StatePaused:
+	default:
+		return
+	}
+	s := g.SavedGame()
+	err := s.Save()
+	if err != nil {
+		log.Printf("could not save game: %v", err)
+		return
+	}
+	g.saved = s
+}
+
+// resume saved game s, paused, exactly as it was saved
+func (g *Game) ResumeGame(s *SavedGame) error {
+	b, err := s.Check()
+	if err != nil {
+		return err
+	}
+
+	// mode: the daily challenge, or racing the ghost
+	g.daily = s.Daily
+	g.ghostMode = s.Ghost != nil
+
+	g.SetBoardSize(b.Width, b.Height)
+	g.Reset(b.Seed)
+	g.LoadBoard(b)
+	g.replay = s.Replay
+	g.stats = s.Stats
+	if s.Ghost != nil {
+		g.StartGhost(s.Ghost)
+		if g.ghost != nil {
+			g.ghost.Seek(s.GhostTick)
+		}
+	}
+	g.ChangeState(StatePaused)
+	return nil
+}
+
+// resume the saved game from the main menu, removing it so it can only be resumed once
+func (g *Game) ContinueGame() {
+	err := g.ResumeGame(g.saved)
+	if err != nil {
+		log.Printf("could not resume saved game: %v", err)
+	}
+	g.saved = nil
+	err = RemoveSavedGame()
+	if err != nil {
+		log.Printf("could not remove saved game: %v", err)
+	}
+}
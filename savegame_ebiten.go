@@ -0,0 +1,43 @@
+//go:build !headless
+
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// is the pause button (ESC, P or gamepad start button) just pressed?
+func (g *Game) PauseJustPressed() bool {
+	pressed := inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyP)
+	for _, id := range g.Gamepads() {
+		pressed = pressed || inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonCenterRight)
+	}
+	return pressed
+}
+
+// update function for when paused: resume, or save the game & exit to the main menu
+func (g *Game) UpdatePaused() error {
+	switch {
+	case g.PauseJustPressed():
+		// not ChangeState, which would start a new replay
+		g.state = StateInGame
+	case inpututil.IsKeyJustPressed(ebiten.KeyS):
+		g.SaveGame()
+		g.ChangeState(StateMainMenu)
+	}
+	return nil
+}
+
+// draw the pause menu
+func (g *Game) DrawPauseMenu(imgOut *ebiten.Image) {
+	_, h := g.ScreenSize()
+	mid := float64(h) / 2
+	g.DrawTextCentred(imgOut, g.T("pause.title"), mid-40, TextStyle{Colour: textHighlightColour, Outline: textOutlineColour})
+	keys := [][2]string{
+		{g.T("key.esc"), g.T("pause.resume")},
+		{g.T("key.s"), g.T("pause.save")},
+		{g.T("key.q"), g.T("menu.quit")},
+	}
+	g.DrawKeyHelp(imgOut, mid-12, keys, TextStyle{Outline: textOutlineColour})
+}
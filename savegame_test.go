@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// a saved game, resumed, plays on exactly as the game it was saved from
+func TestSavedGameResumes(t *testing.T) {
+	h := NewHarness(t, 10, 8, 7, Rules{Topology: "klein"})
+	h.Play("UUUUULLLLDD")
+	h.RunTo(h.tick + 10)
+
+	// save, through the file format
+	b, err := json.Marshal(h.g.SavedGame())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s SavedGame
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewHeadlessGame(10, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.ResumeGame(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.state != StatePaused {
+		t.Fatalf("resumed in state %d, want %d (paused)", g.state, StatePaused)
+	}
+	g.state = StateInGame
+	h2 := &Harness{t: t, g: g, tick: h.tick, script: make(map[int]snakeDirection)}
+	h2.AssertBoard(h.g.Board().String())
+	for _, d := range "RRRUUULLLLLLDDDDDD" {
+		h.Play(string(d))
+		h2.Play(string(d))
+		h2.AssertBoard(h.g.Board().String())
+	}
+	if g.replay.Moves != h.g.replay.Moves {
+		t.Fatalf("resumed game's replay %q, want %q", g.replay.Moves, h.g.replay.Moves)
+	}
+}
+
+// saved games that can't be resumed exactly are rejected
+func TestSavedGameRejected(t *testing.T) {
+	h := NewHarness(t, 10, 8, 7, Rules{})
+	h.Play("UUL")
+	for _, test := range []struct {
+		change func(s *SavedGame)
+		err    string
+	}{
+		{func(s *SavedGame) { s.Version = savedGameVersion + 1 }, "version"},
+		{func(s *SavedGame) { s.Board = "" }, "no board"},
+		{func(s *SavedGame) { s.Board = strings.Replace(s.Board, "state: ingame", "state: gameover", 1) }, "not in progress"},
+		{func(s *SavedGame) { s.Replay.Version-- }, "older version"},
+		{func(s *SavedGame) { s.Replay.Seed++ }, "does not match"},
+	} {
+		s := h.g.SavedGame()
+		r := *s.Replay
+		s.Replay = &r
+		test.change(s)
+		_, err := s.Check()
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("checking saved game: error %v, want %q", err, test.err)
+		}
+	}
+}
+
+// a saved ghost race resumes with the ghost where it was
+func TestSavedGhostRaceResumes(t *testing.T) {
+	best := NewHarness(t, 10, 8, 4, Rules{})
+	best.Play("UUULLLDDDRRRRUUU")
+	h := NewGhostHarness(t, best.g.replay)
+	h.Play("LLUU")
+
+	b, err := json.Marshal(h.g.SavedGame())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s SavedGame
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Ghost == nil || s.GhostTick != h.tick {
+		t.Fatalf("saved ghost at tick %d, want %d", s.GhostTick, h.tick)
+	}
+
+	g, err := NewHeadlessGame(10, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.ResumeGame(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.ghostMode || g.ghost == nil {
+		t.Fatal("resumed without the ghost")
+	}
+	if g.ghost.tick != s.GhostTick {
+		t.Fatalf("resumed ghost at tick %d, want %d", g.ghost.tick, s.GhostTick)
+	}
+	if got, want := g.ghost.g.Board().String(), h.g.ghost.g.Board().String(); got != want {
+		t.Fatalf("resumed ghost's board\n%s\nwant\n%s", got, want)
+	}
+
+	// and races on in step
+	g.state = StateInGame
+	h2 := &Harness{t: t, g: g, tick: h.tick, script: make(map[int]snakeDirection)}
+	h.RunTo(h.tick + 50)
+	h2.RunTo(h2.tick + 50)
+	if got, want := g.ghost.g.Board().String(), h.g.ghost.g.Board().String(); got != want {
+		t.Fatalf("tick %d: resumed ghost's board\n%s\nwant\n%s", h2.tick, got, want)
+	}
+}
+
+// a snake going round a loop of every cell of the board, nearly filling it
+// (so food is placed in one of the last free cells, once random positions keep missing)
+const nearlyFullBoard = `rules: {"walls":true}
+seed: %d
+state: ingame
+direction: L
++########+
+#u>>>>>>>#
+#.<<<<<<v#
+#.v>>>>>>#
+#.<<<<<<v#
+#.v>>>>>>#
+#*<<<<<<v#
+#.v>>>>>>#
+#..L<<<<v#
++########+
+`
+
+// moves taking the snake round the loop twice
+const nearlyFullMoves = "LLUUUUUUURRRRRRRDLLLLLLDRRRRRRDLLLLLLDRRRRRRDLLLLLLDRRRRRRDLLLLLLL" +
+	"UUUUUUURRRRRRRDLLLLLLDRRRRRRDLLLLLLDRRRRRRDLLLLLLDRRRRRRDLLLLL"
+
+// a saved game on a nearly full board, resumed, places food exactly as the game it was saved from
+func TestSavedGameResumesNearlyFull(t *testing.T) {
+	for seed := 1; seed <= 10; seed++ {
+		h := NewHarnessFromBoard(t, fmt.Sprintf(nearlyFullBoard, seed))
+		h.Play(nearlyFullMoves[:10])
+		h.AssertState(StateInGame)
+
+		b, err := json.Marshal(h.g.SavedGame())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s SavedGame
+		err = json.Unmarshal(b, &s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := NewHeadlessGame(8, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = g.ResumeGame(&s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.state = StateInGame
+		h2 := &Harness{t: t, g: g, tick: h.tick, script: make(map[int]snakeDirection)}
+		for _, d := range nearlyFullMoves[10:] {
+			h.Play(string(d))
+			h2.Play(string(d))
+			h2.AssertBoard(h.g.Board().String())
+		}
+		h2.AssertState(StateGameWon)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+// points for eating a cupcake, before bonuses
+const cupcakePoints = 200
+
+// moves after eating a cupcake within which the next must be eaten to keep a combo going
+const comboMoves = 20
+
+// highest combo multiplier
+const comboMax = 5
+
+// snake length per 10% length bonus on cupcakes
+const lengthBonusStep = 10
+
+// points for a tight turn (turning on the move straight after a turn)
+const turnPoints = 10
+
+// points for a shortcut (wrapping around the edge of the board towards the food)
+const shortcutPoints = 50
+
+// where a game's points came from
+type ScoreBreakdown struct {
+	Cupcakes  int `json:"cupcakes"`
+	Combo     int `json:"combo"`
+	Length    int `json:"length"`
+	Turns     int `json:"turns"`
+	Shortcuts int `json:"shortcuts"`
+}
+
+// return the total points of the breakdown
+func (b ScoreBreakdown) Total() int {
+	return b.Cupcakes + b.Combo + b.Length + b.Turns + b.Shortcuts
+}
+
+// scoring state of a game
+type Scoring struct {
+
+	// points scored so far, and where they came from
+	Breakdown ScoreBreakdown
+
+	// cupcakes eaten in a row, each within comboMoves of the last
+	combo int
+
+	// moves since the last cupcake was eaten
+	movesSinceCupcake int
+
+	// was the last move a turn?
+	turned bool
+}
+
+// return the current combo multiplier
+func (s *Scoring) Multiplier() int {
+	return max(1, min(s.combo, comboMax))
+}
+
+// score a cupcake just eaten, returning the points scored
+func (g *Game) ScoreCupcake() int {
+	s := &g.scoring
+	if s.combo > 0 && s.movesSinceCupcake <= comboMoves {
+		s.combo++
+	} else {
+		s.combo = 1
+	}
+	s.movesSinceCupcake = 0
+
+	// combo multiplies the cupcake, then the snake's length adds 10% per lengthBonusStep segments
+	combo := cupcakePoints * (s.Multiplier() - 1)
+	length := (cupcakePoints + combo) * (g.SnakeBody.length / lengthBonusStep) / 10
+	s.Breakdown.Cupcakes += cupcakePoints
+	s.Breakdown.Combo += combo
+	s.Breakdown.Length += length
+	return cupcakePoints + combo + length
+}
+
+// score a movement step from board position x, y, towards food at fx, fy (where it was before the move)
+func (g *Game) ScoreMove(x, y, fx, fy int, turned, wrapped bool) {
+	s := &g.scoring
+	s.movesSinceCupcake++
+	if s.movesSinceCupcake > comboMoves {
+		s.combo = 0
+	}
+
+	// tight turn
+	if turned && s.turned {
+		s.Breakdown.Turns += turnPoints
+	}
+	s.turned = turned
+
+	// shortcut: wrapping around the edge brought the snake closer to the food
+	head := g.SnakeBody.Head()
+	if wrapped && FoodDistance(head.x, head.y, fx, fy) < FoodDistance(x, y, fx, fy) {
+		s.Breakdown.Shortcuts += shortcutPoints
+		g.AddScorePopup(head.x, head.y, shortcutPoints)
+	}
+}
+
+// return the distance from board position x, y to food at fx, fy, without wrapping around the edges
+func FoodDistance(x, y, fx, fy int) int {
+	return abs(fx-x) + abs(fy-y)
+}
+
+// return the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+//go:build !headless
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// draw the combo multiplier (while a combo is going) at the left of the score bar
+func (g *Game) DrawCombo(imgOut *ebiten.Image) {
+	m := g.scoring.Multiplier()
+	if m < 2 {
+		return
+	}
+	g.DrawText(imgOut, g.T("game.combo", m), 4, float64(g.scoreBar.Bounds().Dy()-FONTSIZE)/2, TextStyle{Colour: textHighlightColour})
+}
+
+// draw the breakdown of the game's points, in two columns from y, returning the height drawn
+func (g *Game) DrawScoreBreakdown(imgOut *ebiten.Image, y float64) float64 {
+	b := g.scoring.Breakdown
+	rows := [][2]string{
+		{g.T("score.cupcakes"), fmt.Sprint(b.Cupcakes)},
+		{g.T("score.combo"), fmt.Sprint(b.Combo)},
+		{g.T("score.length"), fmt.Sprint(b.Length)},
+		{g.T("score.turns"), fmt.Sprint(b.Turns)},
+		{g.T("score.shortcuts"), fmt.Sprint(b.Shortcuts)},
+		{g.T("score.total"), fmt.Sprint(b.Total())},
+	}
+	w, _ := g.ScreenSize()
+	left := float64(w)/2 - 90
+	right := float64(w)/2 + 90
+	for i, row := range rows {
+		style := TextStyle{Outline: textOutlineColour}
+		if i == len(rows)-1 {
+			style.Colour = textHighlightColour
+		}
+		ry := y + float64(i)*LINESPACING
+		g.DrawText(imgOut, row[0], left, ry, style)
+		style.Align = text.AlignEnd
+		g.DrawText(imgOut, row[1], right, ry, style)
+	}
+	return float64(len(rows)) * LINESPACING
+}
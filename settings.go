@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// name of the settings file within the data directory
+const settingsFile = "settings.json"
+
+// user settings, saved between runs
+type Settings struct {
+
+	// interpolate snake movement between grid cells when drawing
+	SmoothMovement bool `json:"smoothMovement"`
+
+	// particle effects (crumbs, dust, bone fragments)
+	Particles bool `json:"particles"`
+
+	// shake the screen when the snake dies
+	ScreenShake bool `json:"screenShake"`
+
+	// score pop-ups floating up from eaten food
+	ScorePopups bool `json:"scorePopups"`
+
+	// language code of the message catalogue to use (empty for the system language)
+	Language string `json:"language"`
+
+	// colour palette (see palettes)
+	Palette string `json:"palette"`
+
+	// draw an outline around the snake's head & the food
+	Outline bool `json:"outline"`
+
+	// control scheme (see controlSchemes)
+	Controls string `json:"controls"`
+
+	// board topology for normal games (see topologies)
+	Topology string `json:"topology"`
+
+	// board size for normal games (see worldSizes)
+	WorldSize string `json:"worldSize"`
+
+	// solid tails for normal games, so the snake can't follow right behind its tail (see Rules)
+	TailBlocks bool `json:"tailBlocks"`
+
+	// directory screenshots & GIFs are saved to (empty for "captures" in the data directory)
+	CaptureDir string `json:"captureDir"`
+
+	// length of the rolling buffer saved as a GIF, in seconds (0 to disable)
+	GIFSeconds int `json:"gifSeconds"`
+
+	// fullscreen (toggled with F11)
+	Fullscreen bool `json:"fullscreen"`
+
+	// window size & position, saved when the window is resized or moved
+	WindowWidth      int  `json:"windowWidth"`
+	WindowHeight     int  `json:"windowHeight"`
+	WindowX          int  `json:"windowX"`
+	WindowY          int  `json:"windowY"`
+	WindowPositioned bool `json:"windowPositioned"`
+}
+
+// return the default settings
+func DefaultSettings() Settings {
+	return Settings{
+		SmoothMovement: false,
+		Particles:      true,
+		ScreenShake:    true,
+		ScorePopups:    true,
+		Palette:        "normal",
+		Controls:       ControlsAbsolute,
+		GIFSeconds:     5,
+	}
+}
+
+// return the path of a file within the data directory, creating the directory if needed
+func DataPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "snake")
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// load settings from the data directory
+// if there is no settings file, the default settings are returned
+func LoadSettings() (Settings, error) {
+	s := DefaultSettings()
+	path, err := DataPath(settingsFile)
+	if err != nil {
+		return s, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return DefaultSettings(), fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// save settings to the data directory
+func (s Settings) Save() error {
+	path, err := DataPath(settingsFile)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
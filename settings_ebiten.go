@@ -0,0 +1,190 @@
+//go:build !headless
+
+package main
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// an entry on the settings screen
+type settingsItem struct {
+
+	// message key of the label shown on the settings screen
+	label string
+
+	// returns the current value, as shown on the settings screen
+	value func() string
+
+	// changes the setting to its next value
+	change func()
+}
+
+// the entries on the settings screen
+func (g *Game) SettingsItems() []settingsItem {
+	return []settingsItem{
+		{
+			label: "settings.controls",
+			value: func() string {
+				for _, c := range controlSchemes {
+					if c.id == g.settings.Controls {
+						return g.T(c.name)
+					}
+				}
+				return g.T(controlSchemes[0].name)
+			},
+			change: func() {
+				next := controlSchemes[0].id
+				for i, c := range controlSchemes {
+					if c.id == g.settings.Controls {
+						next = controlSchemes[(i+1)%len(controlSchemes)].id
+					}
+				}
+				g.settings.Controls = next
+			},
+		},
+		{
+			label: "settings.topology",
+			value: func() string {
+				for _, t := range topologies {
+					if t.id == g.settings.Topology {
+						return g.T(t.name)
+					}
+				}
+				return g.T(topologies[0].name)
+			},
+			change: func() {
+				next := topologies[0].id
+				for i, t := range topologies {
+					if t.id == g.settings.Topology {
+						next = topologies[(i+1)%len(topologies)].id
+					}
+				}
+				g.settings.Topology = next
+			},
+		},
+		{
+			label: "settings.world_size",
+			value: func() string {
+				return g.T(g.WorldSize().name)
+			},
+			change: func() {
+				next := worldSizes[0].id
+				for i, w := range worldSizes {
+					if w.id == g.WorldSize().id {
+						next = worldSizes[(i+1)%len(worldSizes)].id
+					}
+				}
+				g.settings.WorldSize = next
+			},
+		},
+		g.ToggleItem("settings.solid_tail", &g.settings.TailBlocks),
+		g.ToggleItem("settings.smooth_movement", &g.settings.SmoothMovement),
+		g.ToggleItem("settings.particles", &g.settings.Particles),
+		g.ToggleItem("settings.screen_shake", &g.settings.ScreenShake),
+		g.ToggleItem("settings.score_popups", &g.settings.ScorePopups),
+		{
+			label: "settings.palette",
+			value: func() string {
+				return g.T(g.Palette().name)
+			},
+			change: func() {
+				next := palettes[0]
+				for i, p := range palettes {
+					if p.id == g.Palette().id {
+						next = palettes[(i+1)%len(palettes)]
+					}
+				}
+				g.settings.Palette = next.id
+				err := g.ApplyPalette()
+				if err != nil {
+					log.Printf("could not change palette: %v", err)
+				}
+			},
+		},
+		g.ToggleItem("settings.outline", &g.settings.Outline),
+		{
+			label: "settings.language",
+			value: func() string {
+				return g.T("language.name")
+			},
+			change: func() {
+				langs := g.Languages()
+				next := langs[0]
+				for i, lang := range langs {
+					if lang == g.settings.Language {
+						next = langs[(i+1)%len(langs)]
+					}
+				}
+				err := g.SetLanguage(next)
+				if err != nil {
+					log.Printf("could not change language: %v", err)
+				}
+			},
+		},
+	}
+}
+
+// a settings screen entry that toggles an on/off setting
+func (g *Game) ToggleItem(label string, setting *bool) settingsItem {
+	return settingsItem{
+		label: label,
+		value: func() string {
+			if *setting {
+				return g.T("settings.on")
+			}
+			return g.T("settings.off")
+		},
+		change: func() {
+			*setting = !*setting
+		},
+	}
+}
+
+// update function for when in settings screen
+func (g *Game) UpdateSettings() error {
+	items := g.SettingsItems()
+
+	// handle input
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp):
+		g.settingsCursor = (g.settingsCursor + len(items) - 1) % len(items)
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown):
+		g.settingsCursor = (g.settingsCursor + 1) % len(items)
+	case inpututil.IsKeyJustPressed(ebiten.KeySpace), inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		items[g.settingsCursor].change()
+		err := g.settings.Save()
+		if err != nil {
+			log.Printf("could not save settings: %v", err)
+		}
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.ChangeState(StateMainMenu)
+	}
+	return nil
+}
+
+// draw the settings screen
+func (g *Game) DrawSettings(imgOut *ebiten.Image) {
+	w, _ := g.ScreenSize()
+	left := float64(w)/2 - 150
+	right := float64(w)/2 + 150
+	g.DrawTextCentred(imgOut, g.T("settings.title"), 50, TextStyle{Colour: textHighlightColour, Outline: textOutlineColour})
+	for i, item := range g.SettingsItems() {
+		style := TextStyle{Outline: textOutlineColour}
+		if i == g.settingsCursor {
+			style.Colour = textHighlightColour
+			g.DrawText(imgOut, ">", left-2*FONTSIZE, float64(85+i*15), style)
+		}
+		g.DrawText(imgOut, g.T(item.label), left, float64(85+i*15), style)
+		style.Align = text.AlignEnd
+		g.DrawText(imgOut, item.value(), right, float64(85+i*15), style)
+	}
+	g.DrawKeyHelp(imgOut, 245, [][2]string{
+		{g.T("key.updown"), g.T("settings.select")},
+		{g.T("key.space"), g.T("settings.change")},
+		{g.T("key.esc"), g.T("settings.back")},
+	}, TextStyle{Outline: textOutlineColour})
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"testing"
+)
+
+// the snake starts in the middle of the board facing up, and first moves after the speed's first ticks
+func TestSnakeMoveTiming(t *testing.T) {
+	h := NewHarness(t, 10, 8, 1, Rules{})
+	h.PlaceFood(0, 0)
+	h.AssertSnake([2]int{5, 4}, [2]int{5, 5}, [2]int{5, 6})
+	h.RunTo(29)
+	h.AssertSnake([2]int{5, 4}, [2]int{5, 5}, [2]int{5, 6})
+	h.RunTo(30)
+	h.AssertSnake([2]int{5, 3}, [2]int{5, 4}, [2]int{5, 5})
+
+	// steering takes effect on the next move, 40 ticks later at normal speed
+	h.Steer(35, LEFT)
+	h.RunTo(69)
+	h.AssertSnake([2]int{5, 3}, [2]int{5, 4}, [2]int{5, 5})
+	h.RunTo(70)
+	h.AssertSnake([2]int{4, 3}, [2]int{5, 3}, [2]int{5, 4})
+	h.AssertOccupancy()
+	h.AssertState(StateInGame)
+}
+
+// the snake can't reverse into itself: steering the opposite way is ignored
+func TestSnakeSteerNoReverse(t *testing.T) {
+	h := NewHarness(t, 10, 8, 1, Rules{})
+	h.PlaceFood(0, 0)
+	h.Play("D")
+	h.AssertSnake([2]int{5, 3}, [2]int{5, 4}, [2]int{5, 5})
+	h.AssertState(StateInGame)
+}
+
+// eating food scores, and the snake grows on the next move
+func TestSnakeMoveEat(t *testing.T) {
+	h := NewHarness(t, 10, 8, 1, Rules{})
+	h.PlaceFood(5, 2)
+	h.Play("U")
+	h.AssertSnake([2]int{5, 3}, [2]int{5, 4}, [2]int{5, 5})
+	h.Play("U")
+	h.AssertSnake([2]int{5, 2}, [2]int{5, 3}, [2]int{5, 4})
+	if h.g.score != 1 || !h.g.SnakeBody.grow {
+		t.Fatalf("score %d, grow %v after eating, want 1, true", h.g.score, h.g.SnakeBody.grow)
+	}
+	if b := h.g.scoring.Breakdown; b.Cupcakes != cupcakePoints || b.Total() != cupcakePoints {
+		t.Fatalf("breakdown %+v after one cupcake, want %d cupcake points", b, cupcakePoints)
+	}
+
+	// new food isn't on the snake
+	if h.g.occupancy.Occupied(h.g.food.x, h.g.food.y) {
+		t.Fatalf("food spawned on the snake at %d,%d", h.g.food.x, h.g.food.y)
+	}
+
+	h.PlaceFood(0, 0)
+	h.Play("L")
+	h.AssertSnake([2]int{4, 2}, [2]int{5, 2}, [2]int{5, 3}, [2]int{5, 4})
+	if h.g.SnakeBody.grow {
+		t.Fatal("snake still growing after growing")
+	}
+	h.AssertOccupancy()
+
+	// each cupcake speeds the snake up
+	if want := h.g.Speed().base - 1; h.g.ticksPerMovement != want {
+		t.Fatalf("%d ticks per movement after one cupcake, want %d", h.g.ticksPerMovement, want)
+	}
+}
+
+// grow the harness's snake (starting at 5,4 facing up) to length 5, ending at 4,2 facing left
+func growSnake(h *Harness) {
+	h.PlaceFood(5, 3)
+	h.Play("U")
+	h.PlaceFood(5, 2)
+	h.Play("U")
+	h.PlaceFood(0, 7)
+	h.Play("L")
+	h.AssertSnake([2]int{4, 2}, [2]int{5, 2}, [2]int{5, 3}, [2]int{5, 4}, [2]int{5, 5})
+}
+
+// running into the snake's own body ends the game
+func TestSnakeMoveSelfCollision(t *testing.T) {
+	h := NewHarness(t, 10, 8, 1, Rules{})
+	growSnake(h)
+	h.Play("D")
+	h.AssertState(StateInGame)
+	h.Play("R")
+	h.AssertState(StateGameEnd)
+
+	// the snake doesn't move into itself
+	h.AssertSnake([2]int{4, 3}, [2]int{4, 2}, [2]int{5, 2}, [2]int{5, 3}, [2]int{5, 4})
+
+	// then turns to skeleton, one segment at a time, and the game is over
+	h.RunTo(h.tick + 100)
+	h.AssertState(StateGameOver)
+	for i := 0; i < h.g.SnakeBody.length; i++ {
+		if !h.g.SnakeBody.Segment(i).skeleton {
+			t.Fatalf("segment %d not skeleton at game over", i)
+		}
+	}
+}
+
+// set up the harness's snake going round a 2x2 square, its head about to move into the cell its tail leaves
+func squareSnake(h *Harness) {
+	h.PlaceFood(5, 3)
+	h.Play("U")
+	h.PlaceFood(0, 7)
+	h.Play("LD")
+	h.AssertSnake([2]int{4, 4}, [2]int{4, 3}, [2]int{5, 3}, [2]int{5, 4})
+}
+
+// the snake can follow its tail, as the tail moves out of the way
+func TestSnakeMoveFollowTail(t *testing.T) {
+	h := NewHarness(t, 10, 8, 1, Rules{})
+	squareSnake(h)
+	h.Play("RULDR")
+	h.AssertState(StateInGame)
+	h.AssertSnake([2]int{5, 4}, [2]int{4, 4}, [2]int{4, 3}, [2]int{5, 3})
+	h.AssertOccupancy()
+}
+
+// with solid tails, following the tail ends the game
+func TestSnakeMoveTailBlocks(t *testing.T) {
+	h := NewHarness(t, 10, 8, 1, Rules{TailBlocks: true})
+	squareSnake(h)
+	h.Play("R")
+	h.AssertState(StateGameEnd)
+}
+
+// the tail doesn't move out of the way while the snake is growing
+func TestSnakeMoveFollowTailGrowing(t *testing.T) {
+	h := NewHarness(t, 10, 8, 1, Rules{})
+	h.PlaceFood(5, 3)
+	h.Play("U")
+	h.PlaceFood(4, 4)
+	h.Play("LD")
+	h.Play("R")
+	h.AssertState(StateGameEnd)
+}
+
+// the tail rules apply to every snake on the board
+func TestSnakeCheckDeathTailRules(t *testing.T) {
+	for _, tailBlocks := range []bool{false, true} {
+		h := NewHarness(t, 10, 8, 1, Rules{TailBlocks: tailBlocks})
+		squareSnake(h)
+		for _, s := range h.g.Snakes() {
+			// the direction taking the snake's head into its tail
+			found := false
+			for _, d := range []snakeDirection{UP, DOWN, LEFT, RIGHT} {
+				x, y, _, ok := h.g.NextPosition(s.Head().x, s.Head().y, d)
+				if !ok || x != s.Tail().x || y != s.Tail().y {
+					continue
+				}
+				found = true
+				if dies := h.g.SnakeCheckDeath(s, d); dies != tailBlocks {
+					t.Errorf("tail blocks %v: following the tail dies %v, want %v", tailBlocks, dies, tailBlocks)
+				}
+			}
+			if !found {
+				t.Fatalf("snake's head at %d,%d is not next to its tail", s.Head().x, s.Head().y)
+			}
+		}
+	}
+}
+
+// the solid tail setting is carried into the rules of a new game, and its replay
+func TestSnakeTailBlocksSetting(t *testing.T) {
+	g, err := NewHeadlessGame(10, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.settings.TailBlocks = true
+	g.ChangeState(StateGameStart)
+	g.ChangeState(StateInGame)
+	if !g.rules.TailBlocks || !g.replay.Rules.TailBlocks {
+		t.Fatalf("rules %+v, replay rules %+v: want solid tails", g.rules, g.replay.Rules)
+	}
+}
+
+// running off the edge of the board wraps around, joins with a flip, or hits a wall, by topology
+func TestSnakeMoveEdges(t *testing.T) {
+	for _, test := range []struct {
+		rules    Rules
+		moves    string
+		state    gameState
+		x, y     int
+		mirrored bool
+	}{
+		{Rules{}, "UUUUU", StateInGame, 5, 7, false},
+		{Rules{}, "UULLLLLL", StateInGame, 9, 2, false},
+		{Rules{Walls: true}, "UUUUU", StateGameEnd, 5, 0, false},
+		{Rules{Topology: "box"}, "UULLLLLL", StateGameEnd, 0, 2, false},
+		{Rules{Topology: "cylinder"}, "UUUUU", StateGameEnd, 5, 0, false},
+		{Rules{Topology: "cylinder"}, "UULLLLLL", StateInGame, 9, 2, false},
+		{Rules{Topology: "klein"}, "UUUUU", StateInGame, 4, 7, true},
+		{Rules{Topology: "klein"}, "UULLLLLL", StateInGame, 9, 2, false},
+		{Rules{Topology: "projective"}, "UUUUU", StateInGame, 4, 7, true},
+		{Rules{Topology: "projective"}, "UULLLLLL", StateInGame, 9, 5, true},
+	} {
+		h := NewHarness(t, 10, 8, 1, test.rules)
+		h.PlaceFood(0, 0)
+		h.Play(test.moves)
+		head := h.g.SnakeBody.Head()
+		if h.g.state != test.state || head.x != test.x || head.y != test.y || head.mirrored != test.mirrored {
+			t.Errorf("%+v %s: state %d, head at %d,%d mirrored %v, want %d, %d,%d mirrored %v", test.rules, test.moves,
+				h.g.state, head.x, head.y, head.mirrored, test.state, test.x, test.y, test.mirrored)
+		}
+		h.AssertOccupancy()
+	}
+}
+
+// filling the board is a perfect game
+func TestSnakeMovePerfectGame(t *testing.T) {
+	h := NewHarness(t, 1, 5, 1, Rules{})
+	h.Play("UUUUUUUUUU")
+	h.AssertState(StateGameWon)
+	if h.g.score != 3 || h.g.SnakeBody.length != 5 || h.g.occupancy.FreeCells() != 0 {
+		t.Fatalf("score %d, length %d, %d free cells at perfect game, want 3, 5, 0", h.g.score, h.g.SnakeBody.length, h.g.occupancy.FreeCells())
+	}
+}
+
+// advancing adds a new head, growing the ring buffer past its initial capacity, and popping the tail removes it
+func TestSnakeAdvancePopTail(t *testing.T) {
+	h := NewHarness(t, 300, 10, 1, Rules{})
+	g := h.g
+	for i := 0; i < 2*snakeInitialCapacity; i++ {
+		g.SnakeAdvance(g.SnakeBody, LEFT)
+	}
+	s := g.SnakeBody
+	if s.length != 2*snakeInitialCapacity+3 {
+		t.Fatalf("length %d, want %d", s.length, 2*snakeInitialCapacity+3)
+	}
+	for i := 0; i < s.length; i++ {
+		seg := s.Segment(i)
+		x, y := 150-2*snakeInitialCapacity+i, 5
+		if i > 2*snakeInitialCapacity {
+			x, y = 150, 5+i-2*snakeInitialCapacity
+		}
+		if seg.x != x || seg.y != y {
+			t.Fatalf("segment %d at %d,%d, want %d,%d", i, seg.x, seg.y, x, y)
+		}
+	}
+	h.AssertOccupancy()
+
+	for s.length > 1 {
+		s.PopTail()
+	}
+	h.AssertSnake([2]int{150 - 2*snakeInitialCapacity, 5})
+	h.AssertOccupancy()
+}
+
+// segment tiles across the edges of the board join up, rotated & mirrored as the board joins
+func TestSnakeTilesAcrossEdges(t *testing.T) {
+	head := func(turns int, mirrored bool) SegmentTile { return SegmentTile{SegmentHead, turns, mirrored} }
+	body := func(turns int, mirrored bool) SegmentTile { return SegmentTile{SegmentBody, turns, mirrored} }
+	bend := func(turns int, mirrored bool) SegmentTile { return SegmentTile{SegmentBend, turns, mirrored} }
+	tail := func(turns int, mirrored bool) SegmentTile { return SegmentTile{SegmentTail, turns, mirrored} }
+	for _, test := range []struct {
+		rules Rules
+		moves string
+		tiles []SegmentTile
+	}{
+		{Rules{}, "UUUUU", []SegmentTile{head(0, false), body(0, false), tail(0, false)}},
+		{Rules{}, "UUUUUL", []SegmentTile{head(3, false), bend(0, false), tail(0, false)}},
+		{Rules{}, "UUUUUR", []SegmentTile{head(1, false), bend(3, false), tail(0, false)}},
+		{Rules{}, "LLLLLLD", []SegmentTile{head(2, false), bend(3, false), tail(3, false)}},
+		{Rules{Topology: "klein"}, "UUUUU", []SegmentTile{head(0, true), body(0, false), tail(0, false)}},
+		{Rules{Topology: "klein"}, "UUUUUL", []SegmentTile{head(3, true), bend(1, true), tail(0, false)}},
+		{Rules{Topology: "klein"}, "UUUUULL", []SegmentTile{head(3, true), body(3, true), tail(3, true)}},
+		{Rules{Topology: "projective"}, "LLLLLLD", []SegmentTile{head(2, true), bend(0, true), tail(3, false)}},
+	} {
+		t.Run(test.rules.Topology+"/"+test.moves, func(t *testing.T) {
+			h := NewHarness(t, 10, 8, 1, test.rules)
+			h.PlaceFood(0, 0)
+			h.Play(test.moves)
+			h.AssertTiles(test.tiles...)
+		})
+	}
+}
+
+// a game played back from its replay ends up the same
+func TestReplayPlaysBackGame(t *testing.T) {
+	h := NewHarness(t, 10, 8, 7, Rules{})
+	h.Play("UULLDDDRRRUUUULLLLLLDDDDDDD")
+	h.RunTo(h.tick + 1000)
+
+	sim, err := NewHeadlessGame(10, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewReplayPlayer(sim, h.g.replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Seek(p.length)
+	if sim.score != h.g.score || sim.scoring.Breakdown != h.g.scoring.Breakdown || sim.state != h.g.state {
+		t.Fatalf("replay ended with score %d %+v, state %d, want %d %+v, state %d",
+			sim.score, sim.scoring.Breakdown, sim.state, h.g.score, h.g.scoring.Breakdown, h.g.state)
+	}
+	for i := 0; i < h.g.SnakeBody.length; i++ {
+		if *sim.SnakeBody.Segment(i) != *h.g.SnakeBody.Segment(i) {
+			t.Fatalf("replay segment %d is %+v, want %+v", i, *sim.SnakeBody.Segment(i), *h.g.SnakeBody.Segment(i))
+		}
+	}
+}
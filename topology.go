@@ -0,0 +1,172 @@
+package main
+
+import (
+	"image"
+)
+
+// how a pair of opposite board edges join up
+type edgeJoin uint8
+
+const (
+
+	// moving off one edge comes back on at the opposite edge
+	edgeWrap edgeJoin = iota
+
+	// as edgeWrap, but flipping the board over (mirroring along the edge)
+	edgeFlip
+
+	// the edges are walls
+	edgeWall
+)
+
+// a board topology: how the edges of the board join up
+type Topology struct {
+
+	// rules value for the topology
+	id string
+
+	// message key of the topology's name
+	name string
+
+	// how the left & right edges join, and how the top & bottom edges join
+	x, y edgeJoin
+}
+
+// available topologies, the classic torus first
+var topologies = []Topology{
+	{"", "topology.torus", edgeWrap, edgeWrap},
+	{"box", "topology.box", edgeWall, edgeWall},
+	{"cylinder", "topology.cylinder", edgeWrap, edgeWall},
+	{"klein", "topology.klein", edgeWrap, edgeFlip},
+	{"projective", "topology.projective", edgeFlip, edgeFlip},
+}
+
+// return the board topology of the current rules (walls make a box, whatever the topology)
+func (g *Game) Topology() Topology {
+	if g.rules.Walls {
+		return topologies[1]
+	}
+	for _, t := range topologies {
+		if t.id == g.rules.Topology {
+			return t
+		}
+	}
+	return topologies[0]
+}
+
+// return the board position one step from x, y in direction d, joining the edges of the board by its topology
+// flipped is true if the step crossed an edge that flips the board over, and ok is false if it ran into a wall
+// (stepping into a wall returns the position wrapped around, as if there was no wall)
+func (g *Game) NextPosition(x, y int, d snakeDirection) (nx, ny int, flipped, ok bool) {
+	t := g.Topology()
+	dx, dy := DirectionDelta(d)
+	nx, ny = x+dx, y+dy
+	ok = true
+	switch {
+	case nx < 0 || nx >= g.width:
+		nx = (nx + g.width) % g.width
+		switch t.x {
+		case edgeFlip:
+			ny = g.height - 1 - ny
+			flipped = true
+		case edgeWall:
+			ok = false
+		}
+	case ny < 0 || ny >= g.height:
+		ny = (ny + g.height) % g.height
+		switch t.y {
+		case edgeFlip:
+			nx = g.width - 1 - nx
+			flipped = true
+		case edgeWall:
+			ok = false
+		}
+	}
+	return nx, ny, flipped, ok
+}
+
+// a pixel position (relative to the board) to draw a tile at, and whether the tile is mirrored there
+type TilePosition struct {
+	x, y    float64
+	flipped bool
+}
+
+// return the pixel position(s) to draw a tile at position x, y
+// if the tile hangs off an edge of the board, it is also drawn hanging off the edge joined to it
+// (mirrored, if the edges join with a flip)
+func (g *Game) WrappedPositions(x, y float64) []TilePosition {
+	t := g.Topology()
+	w := float64(g.width * TILESIZE)
+	h := float64(g.height * TILESIZE)
+	pos := []TilePosition{{x, y, false}}
+
+	// across the left & right edges
+	if t.x != edgeWall && (x < 0 || x > w-TILESIZE) {
+		p := TilePosition{x + w, y, t.x == edgeFlip}
+		if x > 0 {
+			p.x = x - w
+		}
+		if p.flipped {
+			p.y = h - TILESIZE - y
+		}
+		pos = append(pos, p)
+	}
+
+	// across the top & bottom edges (of each position so far)
+	if t.y != edgeWall {
+		for _, p := range pos {
+			if p.y >= 0 && p.y <= h-TILESIZE {
+				continue
+			}
+			q := TilePosition{p.x, p.y + h, p.flipped}
+			if p.y > 0 {
+				q.y = p.y - h
+			}
+			if t.y == edgeFlip {
+				q.x = w - TILESIZE - p.x
+				q.flipped = !p.flipped
+			}
+			pos = append(pos, q)
+		}
+	}
+	return pos
+}
+
+// return the edges of the board to draw, offsetting by yOffset (for score bar):
+// walls, and dashes marking edges that join with a flip
+func (g *Game) EdgeRects(yOffset int) (walls, flips []image.Rectangle) {
+	t := g.Topology()
+	b := image.Rect(0, yOffset, g.width*TILESIZE, yOffset+g.height*TILESIZE)
+	left := image.Rect(b.Min.X, b.Min.Y, b.Min.X+2, b.Max.Y)
+	right := image.Rect(b.Max.X-2, b.Min.Y, b.Max.X, b.Max.Y)
+	top := image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Min.Y+2)
+	bottom := image.Rect(b.Min.X, b.Max.Y-2, b.Max.X, b.Max.Y)
+
+	edges := func(join edgeJoin, a, b image.Rectangle) {
+		switch join {
+		case edgeWall:
+			walls = append(walls, a, b)
+		case edgeFlip:
+			flips = append(flips, dashes(a)...)
+			flips = append(flips, dashes(b)...)
+		}
+	}
+	edges(t.y, top, bottom)
+	edges(t.x, left, right)
+	return walls, flips
+}
+
+// split an edge rectangle into dashes, half a tile long
+func dashes(r image.Rectangle) []image.Rectangle {
+	var d []image.Rectangle
+	if r.Dx() > r.Dy() {
+		for x := r.Min.X; x < r.Max.X; x += TILESIZE {
+			d = append(d, image.Rect(x+TILESIZE/4, r.Min.Y, x+TILESIZE*3/4, r.Max.Y))
+		}
+	} else {
+		for y := r.Min.Y; y < r.Max.Y; y += TILESIZE {
+			d = append(d, image.Rect(r.Min.X, y+TILESIZE/4, r.Max.X, y+TILESIZE*3/4))
+		}
+	}
+	return d
+}
@@ -0,0 +1,105 @@
+//go:build !headless
+
+package main
+
+import (
+	"image"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// how often (in ticks) to check whether the window has been moved or resized
+const windowCheckTicks = 60
+
+// set up the game window, restoring the size & position saved in settings
+func (g *Game) SetupWindow() {
+	screenWidth, screenHeight := g.ScreenSize()
+
+	ebiten.SetWindowTitle("Snake")
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowSizeLimits(screenWidth, screenHeight, -1, -1)
+
+	// window size: saved size, or the largest whole multiple of the screen size that fits comfortably on the monitor
+	if g.settings.WindowWidth > 0 && g.settings.WindowHeight > 0 {
+		ebiten.SetWindowSize(g.settings.WindowWidth, g.settings.WindowHeight)
+	} else {
+		mw, mh := ebiten.Monitor().Size()
+		scale := int(math.Max(1, math.Floor(math.Min(0.8*float64(mw)/float64(screenWidth), 0.8*float64(mh)/float64(screenHeight)))))
+		ebiten.SetWindowSize(screenWidth*scale, screenHeight*scale)
+	}
+
+	// window position: saved position, or leave it to the OS
+	if g.settings.WindowPositioned {
+		ebiten.SetWindowPosition(g.settings.WindowX, g.settings.WindowY)
+	}
+
+	ebiten.SetFullscreen(g.settings.Fullscreen)
+
+	// closing the window quits the game the same way as Q, saving first (see Quit)
+	ebiten.SetWindowClosingHandled(true)
+}
+
+// update window state: toggle fullscreen, and remember the window size & position
+func (g *Game) UpdateWindow() {
+	changed := false
+
+	// F11 toggles fullscreen
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		g.settings.Fullscreen = !ebiten.IsFullscreen()
+		ebiten.SetFullscreen(g.settings.Fullscreen)
+		changed = true
+	}
+
+	// periodically check whether the window has been moved or resized
+	g.windowTicks++
+	if g.windowTicks >= windowCheckTicks {
+		g.windowTicks = 0
+		changed = g.CheckWindow() || changed
+	}
+
+	if changed {
+		err := g.settings.Save()
+		if err != nil {
+			log.Printf("could not save settings: %v", err)
+		}
+	}
+}
+
+// remember the window's size & position in settings (unless fullscreen or minimised)
+// returns true if they have changed
+func (g *Game) CheckWindow() bool {
+	if ebiten.IsFullscreen() || ebiten.IsWindowMinimized() {
+		return false
+	}
+	w, h := ebiten.WindowSize()
+	x, y := ebiten.WindowPosition()
+	if w == g.settings.WindowWidth && h == g.settings.WindowHeight && x == g.settings.WindowX && y == g.settings.WindowY {
+		return false
+	}
+	g.settings.WindowWidth = w
+	g.settings.WindowHeight = h
+	g.settings.WindowX = x
+	g.settings.WindowY = y
+	g.settings.WindowPositioned = true
+	return true
+}
+
+// work out where to draw the game frame on a screen of size sw x sh pixels:
+// scaled up by the largest whole number that fits (for crisp pixels), and centred (letterboxed)
+func (g *Game) FrameRect(sw, sh int) (rect image.Rectangle, scale int) {
+	w, h := g.ScreenSize()
+	scale = max(1, min(sw/w, sh/h))
+	x := (sw - w*scale) / 2
+	y := (sh - h*scale) / 2
+	return image.Rect(x, y, x+w*scale, y+h*scale), scale
+}
+
+// return the mouse cursor position in game frame pixels
+func (g *Game) CursorPosition() (x, y int) {
+	cx, cy := ebiten.CursorPosition()
+	rect, scale := g.FrameRect(g.screenWidth, g.screenHeight)
+	return (cx - rect.Min.X) / scale, (cy - rect.Min.Y) / scale
+}